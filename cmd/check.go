@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+
+	"nodepp/internal/health"
+	"nodepp/internal/structs"
+)
+
+// Exit codes returned by --check. Errors collecting the cluster data exit
+// with 1 as before.
+const (
+	ExitDegraded = 2
+	ExitCritical = 3
+)
+
+// ExitError reports that the command should exit with a specific code
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// check evaluates the cluster data against the health rules, printing the
+// reasons it is unhealthy and returning an ExitError unless it is healthy
+func (dp *nodePPCommand) check(cd *structs.ClusterData) error {
+	opts := health.Options{Thresholds: dp.loadedConfig().Thresholds}
+	if hotNodes := dp.loadedConfig().Check.HotNodes; hotNodes != "" {
+		severity, err := health.ParseSeverity(hotNodes)
+		if err != nil {
+			return fmt.Errorf("invalid check.hotNodes in config file: %v", err)
+		}
+		opts.HotNodes = severity
+	}
+
+	report := health.Evaluate(cd, opts)
+	status := report.Status()
+
+	fmt.Fprintf(dp.out, "Cluster is %s\n", status)
+	for _, finding := range report.Findings {
+		fmt.Fprintf(dp.out, " %s: %s\n", finding.Severity, finding.Reason)
+	}
+
+	switch status {
+	case health.Critical:
+		return &ExitError{Code: ExitCritical, Err: fmt.Errorf("cluster is %s", status)}
+	case health.Degraded:
+		return &ExitError{Code: ExitDegraded, Err: fmt.Errorf("cluster is %s", status)}
+	}
+	return nil
+}
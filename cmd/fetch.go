@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	oapi "github.com/openshift/api/config/v1"
+	"github.com/openshift/api/machine/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+
+	"nodepp/internal/structs"
+)
+
+// fetchFunc retrieves a single data source from the API server. Optional
+// sources that fail are reported as warnings rather than errors.
+type fetchFunc struct {
+	name     string
+	optional bool
+	fetch    func(ctx context.Context) error
+}
+
+// fetchResult holds the raw API responses gathered for a single collection
+type fetchResult struct {
+	warnings    []structs.Warning
+	nodes       []v1.Node
+	machines    *v1beta1.MachineList
+	machineSets *v1beta1.MachineSetList
+	nodeMetrics *metricsv1beta1.NodeMetricsList
+	pods        []v1.Pod
+	version     *oapi.ClusterVersion
+	operators   *oapi.ClusterOperatorList
+	pools       *unstructured.UnstructuredList
+}
+
+// fetchAll runs all fetches concurrently with a shared context and waits for
+// them to finish. Failures of required fetches are combined into a single
+// error report, while failures of optional fetches are returned as warnings.
+// The first required failure cancels the fetches still in flight, as their
+// results would be discarded.
+func fetchAll(ctx context.Context, fetches []fetchFunc) ([]structs.Warning, error) {
+	fetchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(fetches))
+	for i, f := range fetches {
+		wg.Add(1)
+		go func(i int, f fetchFunc) {
+			defer wg.Done()
+			errs[i] = f.fetch(fetchCtx)
+			if errs[i] != nil && !f.optional {
+				cancel()
+			}
+		}(i, f)
+	}
+	wg.Wait()
+
+	warnings := make([]structs.Warning, 0)
+	required := make([]error, 0)
+	for i, f := range fetches {
+		if errs[i] == nil {
+			continue
+		}
+		if ctx.Err() == nil && errors.Is(errs[i], context.Canceled) {
+			// cancelled because another required fetch failed
+			continue
+		}
+		if f.optional {
+			warnings = append(warnings, structs.NewWarning(f.name, errs[i]))
+		} else {
+			required = append(required, fmt.Errorf("%s: %w", f.name, errs[i]))
+		}
+	}
+	return warnings, utilerrors.NewAggregate(required)
+}
+
+// merge joins the fetched data into a single cluster view. The merge is done
+// in a fixed order once all fetches have completed, so the result does not
+// depend on which fetch finished first.
+func (r *fetchResult) merge() (*structs.ClusterData, error) {
+	cd := new(structs.ClusterData)
+	cd.Nodes = make([]*structs.NodeData, 0, len(r.nodes))
+
+	for i := range r.nodes {
+		nodeData, err := structs.NewFromNode(&r.nodes[i])
+		if err != nil {
+			return nil, err
+		}
+		cd.Nodes = append(cd.Nodes, nodeData)
+	}
+
+	if r.machines != nil {
+		for i := range r.machines.Items {
+			nodeData, err := structs.NewFromMachine(&r.machines.Items[i])
+			if err != nil {
+				return nil, err
+			}
+			cd.AddMachine(nodeData)
+		}
+	}
+
+	if r.machineSets != nil {
+		machineSets := make([]*structs.MachineSetData, 0, len(r.machineSets.Items))
+		for i := range r.machineSets.Items {
+			machineSets = append(machineSets, structs.NewFromMachineSet(&r.machineSets.Items[i]))
+		}
+		cd.AddMachineSets(machineSets)
+	}
+
+	if r.nodeMetrics != nil {
+		for i := range r.nodeMetrics.Items {
+			cd.AddNodeMetrics(&r.nodeMetrics.Items[i])
+		}
+	}
+
+	if r.pods != nil {
+		cd.AddPods(r.pods)
+	}
+
+	if r.pools != nil {
+		pools := make([]*structs.PoolData, 0, len(r.pools.Items))
+		for i := range r.pools.Items {
+			poolData, err := structs.NewFromMachineConfigPool(&r.pools.Items[i])
+			if err != nil {
+				return nil, err
+			}
+			pools = append(pools, poolData)
+		}
+		cd.AddPools(pools)
+	}
+
+	cd.Version = r.version
+	cd.ClusterOperators = r.operators
+	cd.Warnings = r.warnings
+	return cd, nil
+}
@@ -0,0 +1,281 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	oapi "github.com/openshift/api/config/v1"
+	"github.com/openshift/api/machine/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+
+	"nodepp/internal/structs"
+)
+
+// fixtureFetches returns fetches that fill res with fixture data
+func fixtureFetches(res *fetchResult) []fetchFunc {
+	running := "Running"
+	provisioning := "Provisioning"
+	assign := []func(){
+		func() {
+			res.nodes = []v1.Node{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "node-a"},
+					Status: v1.NodeStatus{Allocatable: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse("4"),
+						v1.ResourceMemory: resource.MustParse("16Gi"),
+					}},
+				},
+			}
+		},
+		func() {
+			res.machines = &v1beta1.MachineList{Items: []v1beta1.Machine{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "machine-a"},
+					Status: v1beta1.MachineStatus{
+						NodeRef: &v1.ObjectReference{Kind: "Node", Name: "node-a"},
+						Phase:   &running,
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "machine-b"},
+					Status:     v1beta1.MachineStatus{Phase: &provisioning},
+				},
+			}}
+		},
+		func() {
+			res.nodeMetrics = &metricsv1beta1.NodeMetricsList{Items: []metricsv1beta1.NodeMetrics{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "node-a"},
+					Usage: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse("1500m"),
+						v1.ResourceMemory: resource.MustParse("4Gi"),
+					},
+				},
+			}}
+		},
+		func() {
+			res.version = &oapi.ClusterVersion{ObjectMeta: metav1.ObjectMeta{Name: "version"}}
+		},
+		func() {
+			res.operators = &oapi.ClusterOperatorList{Items: []oapi.ClusterOperator{
+				{ObjectMeta: metav1.ObjectMeta{Name: "dns"}},
+			}}
+		},
+	}
+
+	fetches := make([]fetchFunc, len(assign))
+	for i := range assign {
+		i := i
+		fetches[i] = fetchFunc{name: "fixture", fetch: func(ctx context.Context) error {
+			assign[i]()
+			return nil
+		}}
+	}
+	return fetches
+}
+
+// TestMergeJoinsFetchedSources checks that the fetched nodes, machines and
+// metrics are merged into rows, including machines without a node
+func TestMergeJoinsFetchedSources(t *testing.T) {
+	res := new(fetchResult)
+	if _, err := fetchAll(context.Background(), fixtureFetches(res)); err != nil {
+		t.Fatal(err)
+	}
+	cd, err := res.merge()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cd.Nodes) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(cd.Nodes))
+	}
+	node := cd.GetNode("node-a")
+	if node.MachinePhase != "Running" || node.Cpu.Utilization.MilliValue() != 1500 {
+		t.Errorf("node-a was not merged with its machine and metrics")
+	}
+	if n := cd.GetNode("machine-b"); n == nil || n.NodeName != "" {
+		t.Errorf("expected machine-b to be listed without a node")
+	}
+}
+
+// releaseInOrder runs the fetches with each one held until it is released,
+// releasing them one at a time in the given order so that they finish in that
+// order, and returns the merged result
+func releaseInOrder(t *testing.T, res *fetchResult, fetches []fetchFunc, order []int) *structs.ClusterData {
+	t.Helper()
+	release := make([]chan struct{}, len(fetches))
+	finished := make(chan int)
+	gated := make([]fetchFunc, len(fetches))
+	for i, f := range fetches {
+		i, f := i, f
+		release[i] = make(chan struct{})
+		gated[i] = fetchFunc{name: f.name, optional: f.optional, fetch: func(ctx context.Context) error {
+			<-release[i]
+			defer func() { finished <- i }()
+			return f.fetch(ctx)
+		}}
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := fetchAll(context.Background(), gated)
+		errs <- err
+	}()
+	for _, i := range order {
+		close(release[i])
+		select {
+		case got := <-finished:
+			if got != i {
+				t.Fatalf("fetch %d finished while waiting for fetch %d", got, i)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("fetch %d did not finish", i)
+		}
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+
+	cd, err := res.merge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cd
+}
+
+// TestMergeIndependentOfFetchOrder checks that the merge result is the same
+// whatever order the fetches finish in
+func TestMergeIndependentOfFetchOrder(t *testing.T) {
+	orders := [][]int{
+		{0, 1, 2, 3, 4},
+		{4, 3, 2, 1, 0},
+		{2, 0, 4, 1, 3},
+		{1, 3, 0, 4, 2},
+	}
+
+	var want *structs.ClusterData
+	for _, order := range orders {
+		res := new(fetchResult)
+		fetches := fixtureFetches(res)
+		if len(fetches) != len(order) {
+			t.Fatalf("order %v does not cover the %d fixture fetches", order, len(fetches))
+		}
+		cd := releaseInOrder(t, res, fetches, order)
+		if want == nil {
+			want = cd
+			continue
+		}
+		if !reflect.DeepEqual(cd, want) {
+			t.Errorf("merge result for fetch order %v differs from order %v", order, orders[0])
+		}
+	}
+}
+
+func TestFetchAllCombinesErrors(t *testing.T) {
+	fetches := []fetchFunc{
+		{name: "nodes", fetch: func(ctx context.Context) error { return errors.New("forbidden") }},
+		{name: "pods", fetch: func(ctx context.Context) error { return errors.New("timeout") }},
+		{name: "node metrics", optional: true, fetch: func(ctx context.Context) error { return nil }},
+	}
+	_, err := fetchAll(context.Background(), fetches)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{"nodes: forbidden", "pods: timeout"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error %q to contain %q", err.Error(), want)
+		}
+	}
+}
+
+func TestFetchAllCancelsOnRequiredFailure(t *testing.T) {
+	cancelled := make(chan struct{})
+	fetches := []fetchFunc{
+		{name: "nodes", fetch: func(ctx context.Context) error { return errors.New("forbidden") }},
+		{name: "pods", fetch: func(ctx context.Context) error {
+			<-ctx.Done()
+			close(cancelled)
+			return ctx.Err()
+		}},
+		{name: structs.SourceNodeMetrics, optional: true, fetch: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := fetchAll(context.Background(), fetches)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil || err.Error() != "nodes: forbidden" {
+			t.Errorf("expected only the required failure to be reported, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("in-flight fetches were not cancelled")
+	}
+	select {
+	case <-cancelled:
+	default:
+		t.Errorf("expected the pods fetch to see the cancellation")
+	}
+}
+
+func TestFetchAllOptionalFailuresDoNotMaskRequired(t *testing.T) {
+	// the optional fetch fails first, and the required one only afterwards
+	optionalFailed := make(chan struct{})
+	fetches := []fetchFunc{
+		{name: structs.SourceNodeMetrics, optional: true, fetch: func(ctx context.Context) error {
+			defer close(optionalFailed)
+			return errors.New("service unavailable")
+		}},
+		{name: "nodes", fetch: func(ctx context.Context) error {
+			<-optionalFailed
+			return errors.New("forbidden")
+		}},
+	}
+	warnings, err := fetchAll(context.Background(), fetches)
+	if err == nil || !strings.Contains(err.Error(), "nodes: forbidden") {
+		t.Errorf("expected the required failure to be returned, got %v", err)
+	}
+	if len(warnings) != 1 || warnings[0].Source != structs.SourceNodeMetrics {
+		t.Errorf("expected the optional failure as a warning, got %v", warnings)
+	}
+}
+
+func TestFetchAllDegradesOptionalSources(t *testing.T) {
+	fetches := []fetchFunc{
+		{name: "nodes", fetch: func(ctx context.Context) error { return nil }},
+		{name: structs.SourceMachines, optional: true, fetch: func(ctx context.Context) error {
+			return errors.New("the server could not find the requested resource")
+		}},
+		{name: structs.SourceNodeMetrics, optional: true, fetch: func(ctx context.Context) error {
+			return errors.New("service unavailable")
+		}},
+	}
+	warnings, err := fetchAll(context.Background(), fetches)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	res := &fetchResult{warnings: warnings}
+	cd, err := res.merge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cd.Unavailable(structs.SourceMachines) || !cd.Unavailable(structs.SourceNodeMetrics) {
+		t.Errorf("expected machines and node metrics to be unavailable, got %v", cd.Warnings)
+	}
+	if cd.Unavailable(structs.SourceClusterVersion) {
+		t.Errorf("cluster version should not be reported as unavailable")
+	}
+}
@@ -2,16 +2,25 @@ package cmd
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 	"io"
 	"nodepp/internal/structs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
-	"k8s.io/client-go/rest"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
 	mcs "k8s.io/metrics/pkg/client/clientset/versioned"
@@ -19,7 +28,7 @@ import (
 	oapi "github.com/openshift/api/config/v1"
 	"github.com/openshift/api/machine/v1beta1"
 	configclient "github.com/openshift/client-go/config/clientset/versioned"
-	machinev1 "github.com/openshift/client-go/machine/clientset/versioned/typed/machine/v1beta1"
+	machineclient "github.com/openshift/client-go/machine/clientset/versioned"
 
 	"nodepp/internal/config"
 	"nodepp/internal/consts"
@@ -43,20 +52,48 @@ var (
 	showUsage     bool
 	showKeys      bool
 	showVersion   bool
+	showUpgrade   bool
 	showOperators bool
+	showPools     bool
+	showTopology  bool
 	nodeLabels    string
+	output        string
+	watch         bool
+	watchInterval time.Duration
+	check         bool
+	configFile    string
+	noEmoji       bool
+	plain         bool
+	themeName     string
+	only          []string
+	sortBy        []string
+	groupBy       string
+	customColumns []string
 )
 
 type nodePPCommand struct {
-	out        io.Writer
-	f          cmdutil.Factory
-	clientset  *kubernetes.Clientset
-	restConfig *rest.Config
+	out    io.Writer
+	errOut io.Writer
+	f      cmdutil.Factory
+
+	// API clients, created from the factory unless already provided
+	kubeClient    kubernetes.Interface
+	machineClient machineclient.Interface
+	metricsClient mcs.Interface
+	configClient  configclient.Interface
+	dynamicClient dynamic.Interface
+
+	// settings holds the thresholds and rules read from the config file
+	settings *config.File
+
+	// filter selects the rows to display, if --only was given
+	filter *structs.StatusFilter
 }
 
 func NewNodePPCommand(streams genericclioptions.IOStreams) *cobra.Command {
 	dpcmd := &nodePPCommand{
-		out: streams.Out,
+		out:    streams.Out,
+		errOut: streams.ErrOut,
 	}
 
 	ccmd := &cobra.Command{
@@ -65,16 +102,43 @@ func NewNodePPCommand(streams genericclioptions.IOStreams) *cobra.Command {
 		Long:         longDescription,
 		SilenceUsage: true,
 		Args:         cobra.MaximumNArgs(1),
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := dpcmd.loadConfig(cmd); err != nil {
+				return err
+			}
+			return checkOutputFlag()
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return dpcmd.run(args)
+			err := dpcmd.run(cmd.Context(), args)
+			var exitErr *ExitError
+			if errors.As(err, &exitErr) {
+				// the check has already reported why it failed
+				cmd.SilenceErrors = true
+			}
+			return err
 		},
 	}
 
 	ccmd.PersistentFlags().BoolVarP(&showUsage, config.ShowUsage, "u", true, "Show node resource usage")
 	ccmd.PersistentFlags().BoolVarP(&showVersion, config.ShowVersion, "v", true, "Show cluster version data")
-	ccmd.PersistentFlags().BoolVarP(&showOperators, config.ShowOperators, "o", true, "Show cluster operator data")
+	ccmd.PersistentFlags().BoolVar(&showUpgrade, config.ShowUpgradeDetails, false, "Show detailed cluster version and update status")
+	ccmd.PersistentFlags().BoolVar(&showOperators, config.ShowOperators, true, "Show cluster operator data")
+	ccmd.PersistentFlags().BoolVar(&showPools, config.ShowPools, false, "Show MachineConfigPool data")
+	ccmd.PersistentFlags().BoolVar(&showTopology, config.ShowTopology, false, "Show the availability zone and instance type of each node")
 	ccmd.PersistentFlags().BoolVarP(&showKeys, config.ShowKeys, "k", false, "Show symbol keys")
 	ccmd.PersistentFlags().StringVarP(&nodeLabels, config.NodeLabels, "l", "", "Filter by node labels")
+	ccmd.Flags().StringSliceVar(&sortBy, config.SortBy, nil, "Sort rows by the given fields, each optionally suffixed with :asc or :desc. Any of: "+strings.Join(structs.SortFields, "|"))
+	ccmd.PersistentFlags().StringVar(&groupBy, config.GroupBy, "", "Group rows by the given field, with a summary for each group. One of: "+strings.Join(structs.GroupFields, "|"))
+	ccmd.Flags().StringArrayVar(&customColumns, config.Columns, nil, "Extra column as HEADER:JSONPATH, evaluated against the Node, or HEADER:machine:JSONPATH, evaluated against the Machine. Repeat the flag for more columns")
+	ccmd.Flags().StringSliceVar(&only, config.Only, nil, "Only show rows in any of the given states. Any of: "+strings.Join(structs.Statuses, "|"))
+	ccmd.PersistentFlags().StringVarP(&output, config.Output, "o", outputter.FormatTable, "Output format. One of: table|wide|json|yaml|go-template=TEMPLATE|go-template-file=PATH")
+	ccmd.PersistentFlags().BoolVarP(&watch, config.Watch, "w", false, "Continuously refresh the output")
+	ccmd.PersistentFlags().DurationVar(&watchInterval, config.WatchInterval, 5*time.Second, "Refresh interval when watching")
+	ccmd.PersistentFlags().StringVar(&themeName, config.Theme, "", "Theme for status symbols and colours. One of: "+strings.Join(outputter.ThemeNames(), "|"))
+	ccmd.PersistentFlags().BoolVar(&noEmoji, config.NoEmoji, false, "Mark states with text codes instead of emoji, the same as --theme="+outputter.ThemeColourOnly)
+	ccmd.PersistentFlags().BoolVar(&plain, config.Plain, false, "Print plain ASCII output without emoji or colours, the same as --theme="+outputter.ThemeASCII)
+	ccmd.PersistentFlags().StringVar(&configFile, config.ConfigFile, config.DefaultPath(), "Path to the config file holding thresholds and flag defaults")
+	ccmd.Flags().BoolVar(&check, config.Check, false, "Check cluster health and exit non-zero if it is degraded or critical")
 
 	fsets := ccmd.PersistentFlags()
 	cfgFlags := genericclioptions.NewConfigFlags(true)
@@ -83,168 +147,360 @@ func NewNodePPCommand(streams genericclioptions.IOStreams) *cobra.Command {
 	matchVersionFlags.AddFlags(fsets)
 	dpcmd.f = cmdutil.NewFactory(matchVersionFlags)
 
+	// the operators view is the only subcommand, so cobra's completion command is
+	// left out rather than taking another name from node names
+	ccmd.CompletionOptions.DisableDefaultCmd = true
+	ccmd.AddCommand(newOperatorsCommand(dpcmd))
+
 	return ccmd
 }
 
-func (dp *nodePPCommand) run(args []string) error {
-	// Setup clients
-	clientset, err := dp.f.KubernetesClientSet()
+func (dp *nodePPCommand) run(ctx context.Context, args []string) error {
+	if watch && check {
+		return fmt.Errorf("--%s cannot be used with --%s", config.Check, config.Watch)
+	}
+	if watch && outputter.IsTemplateFormat(output) {
+		return fmt.Errorf("go-template output cannot be used with --%s", config.Watch)
+	}
+	opts, err := dp.renderOptions()
 	if err != nil {
 		return err
 	}
-	dp.clientset = clientset
-	rc, err := dp.f.ToRESTConfig()
+	renderer, err := outputter.NewRenderer(output, opts)
 	if err != nil {
 		return err
 	}
-	dp.restConfig = rc
-
-	// Initialise data store
-	cd := new(structs.ClusterData)
-	cd.Nodes = make([]*structs.NodeData, 0)
-
-	// Pull node info
-	nodesToProcess := make([]v1.Node, 0)
-	if len(args) == 1 {
-		node, err := dp.clientset.CoreV1().Nodes().Get(context.Background(), args[0], metav1.GetOptions{})
-		if err != nil {
-			return err
-		}
-		nodesToProcess = append(nodesToProcess, *node)
-	} else {
-		nodes, err := dp.clientset.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{
-			LabelSelector: nodeLabels,
-		})
+	if len(only) > 0 {
+		dp.filter, err = structs.NewStatusFilter(only, opts.Thresholds)
 		if err != nil {
 			return err
 		}
+	}
 
-		for _, node := range nodes.Items {
-			nodesToProcess = append(nodesToProcess, node)
-		}
+	if err := dp.setupClients(); err != nil {
+		return err
 	}
 
-	// Process each node
-	for _, node := range nodesToProcess {
-		nodeData, err := structs.NewFromNode(&node)
-		if err != nil {
-			return err
-		}
-		cd.Nodes = append(cd.Nodes, nodeData)
+	if watch {
+		return dp.watch(ctx, args, renderer)
 	}
 
-	// Process machines
-	machines, err := dp.getAllMachines()
+	cd, err := dp.collect(ctx, args)
 	if err != nil {
 		return err
 	}
-	for _, machine := range machines.Items {
-		nodeData, err := structs.NewFromMachine(&machine)
-		if err != nil {
-			return err
-		}
-		if nodeData.NodeName == "" {
-			cd.Nodes = append(cd.Nodes, nodeData)
-		} else {
-			// just merge in machine info, if we pulled node info originally
-			node := cd.GetNode(nodeData.NodeName)
-			if node != nil {
-				node.MachinePhase = nodeData.MachinePhase
-			}
+
+	if check {
+		return dp.check(cd)
+	}
+
+	// Render output
+	dp.prepare(cd)
+	return renderer.Render(cd, dp.out)
+}
+
+// prepare selects the rows to display
+func (dp *nodePPCommand) prepare(cd *structs.ClusterData) {
+	if dp.filter != nil {
+		cd.Filter(dp.filter.Matches)
+	}
+}
+
+// renderOptions returns the renderer options selected by the flags and config file
+func (dp *nodePPCommand) renderOptions() (outputter.Options, error) {
+	theme, err := dp.theme()
+	if err != nil {
+		return outputter.Options{}, err
+	}
+	sortKeys, err := structs.ParseSortKeys(sortBy)
+	if err != nil {
+		return outputter.Options{}, err
+	}
+	if groupBy != "" {
+		if err := structs.ValidateGroupBy(groupBy); err != nil {
+			return outputter.Options{}, err
 		}
 	}
+	columns, err := outputter.ParseCustomColumns(customColumns)
+	if err != nil {
+		return outputter.Options{}, err
+	}
+	return outputter.Options{
+		ShowUsage:          showUsage,
+		ShowPools:          showPools,
+		ShowUpgradeDetails: showUpgrade,
+		ShowTopology:       showTopology,
+		ShowKeys:           showKeys,
+		HighlightChanges:   watch,
+		Thresholds:         dp.loadedConfig().Thresholds,
+		SortBy:             sortKeys,
+		GroupBy:            groupBy,
+		CustomColumns:      columns,
+		Theme:              theme,
+		NoColor:            !dp.useColor(),
+		Stream:             watch,
+	}, nil
+}
+
+// theme returns the theme named by the flags, or by the config file if no
+// theme flag was given, with the config file overrides applied
+func (dp *nodePPCommand) theme() (outputter.Theme, error) {
+	settings := dp.loadedConfig().Theme
+	name := themeName
+	switch {
+	case plain:
+		name = outputter.ThemeASCII
+	case noEmoji:
+		name = outputter.ThemeColourOnly
+	case name == "" && settings.Name != "":
+		name = settings.Name
+	case name == "":
+		name = outputter.ThemeEmoji
+	}
+	return outputter.NewTheme(name, settings)
+}
+
+// useColor reports whether output should be coloured. Colours are disabled
+// for plain output, when NO_COLOR is set, and when not writing to a terminal.
+func (dp *nodePPCommand) useColor() bool {
+	if plain || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return dp.isTerminal()
+}
+
+// isTerminal reports whether output is written to a terminal
+func (dp *nodePPCommand) isTerminal() bool {
+	f, ok := dp.out.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// loadConfig reads the config file and applies its flag defaults to any flag
+// not given on the command line
+func (dp *nodePPCommand) loadConfig(cmd *cobra.Command) error {
+	settings, err := config.Load(configFile, cmd.Flags().Changed(config.ConfigFile))
+	if err != nil {
+		return err
+	}
+	if err := settings.ApplyDefaults(cmd.Flags(), cmd.Root().LocalFlags()); err != nil {
+		return err
+	}
+	if !cmd.Flags().Changed(config.Columns) && len(settings.Columns) > 0 {
+		customColumns = settings.Columns
+	}
+	dp.settings = settings
+	return nil
+}
+
+// checkOutputFlag points users of the old -o shorthand, which belonged to
+// --show-operators before it was given to --output, at the long flag
+func checkOutputFlag() error {
+	if _, err := strconv.ParseBool(output); err == nil {
+		return fmt.Errorf("unsupported output format %q: -o now selects the output format, use --%s=%s to show or hide cluster operators",
+			output, config.ShowOperators, output)
+	}
+	return nil
+}
+
+// loadedConfig returns the loaded config file, or an empty one if none was loaded
+func (dp *nodePPCommand) loadedConfig() *config.File {
+	if dp.settings == nil {
+		return new(config.File)
+	}
+	return dp.settings
+}
 
-	// Process node metrics
+// setupClients creates the API clients once so they can be reused between
+// refreshes. Clients that have already been set, such as fakes injected by
+// tests, are left in place.
+func (dp *nodePPCommand) setupClients() error {
+	if dp.kubeClient != nil {
+		return nil
+	}
+	clientset, err := dp.f.KubernetesClientSet()
+	if err != nil {
+		return err
+	}
+	dp.kubeClient = clientset
+	rc, err := dp.f.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+
+	dp.machineClient, err = machineclient.NewForConfig(rc)
+	if err != nil {
+		return err
+	}
+	dp.metricsClient, err = mcs.NewForConfig(rc)
+	if err != nil {
+		return err
+	}
+	dp.configClient, err = configclient.NewForConfig(rc)
+	if err != nil {
+		return err
+	}
+	dp.dynamicClient, err = dynamic.NewForConfig(rc)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// collect fetches node, machine, metrics and cluster data concurrently and
+// merges the results. Only the node data is required; other sources that
+// cannot be retrieved are reported as warnings.
+func (dp *nodePPCommand) collect(ctx context.Context, args []string) (*structs.ClusterData, error) {
+	res := new(fetchResult)
+	fetches := []fetchFunc{
+		{name: "nodes", fetch: func(ctx context.Context) (err error) {
+			res.nodes, err = dp.getNodes(ctx, args)
+			return err
+		}},
+		{name: structs.SourceMachines, optional: true, fetch: func(ctx context.Context) (err error) {
+			res.machines, err = dp.getAllMachines(ctx)
+			return err
+		}},
+	}
+	if groupBy == structs.GroupByMachineSet {
+		fetches = append(fetches, fetchFunc{name: structs.SourceMachineSets, optional: true, fetch: func(ctx context.Context) (err error) {
+			res.machineSets, err = dp.getAllMachineSets(ctx)
+			return err
+		}})
+	}
 	if showUsage {
-		nodeMetrics, err := dp.getNodeMetrics()
-		if err != nil {
+		fetches = append(fetches, fetchFunc{name: structs.SourceNodeMetrics, optional: true, fetch: func(ctx context.Context) (err error) {
+			res.nodeMetrics, err = dp.getNodeMetrics(ctx)
 			return err
-		}
-		for _, nm := range nodeMetrics.Items {
-			// ignore nodes we never pulled info for originally
-			node := cd.GetNode(nm.Name)
-			if node == nil {
-				continue
-			}
-			if node.Cpu != nil {
-				node.Cpu.Utilization = nm.Usage.Cpu().DeepCopy()
-			}
-			if node.Memory != nil {
-				node.Memory.Utilization = nm.Usage.Memory().DeepCopy()
-			}
-		}
+		}})
+		fetches = append(fetches, fetchFunc{name: structs.SourcePods, optional: true, fetch: func(ctx context.Context) (err error) {
+			res.pods, err = dp.getPods(ctx, args)
+			return err
+		}})
+	}
+	if showVersion || showUpgrade {
+		fetches = append(fetches, fetchFunc{name: structs.SourceClusterVersion, optional: true, fetch: func(ctx context.Context) (err error) {
+			res.version, err = dp.getClusterVersion(ctx)
+			return err
+		}})
+	}
+	if showOperators {
+		fetches = append(fetches, fetchFunc{name: structs.SourceClusterOperators, optional: true, fetch: func(ctx context.Context) (err error) {
+			res.operators, err = dp.getClusterOperators(ctx)
+			return err
+		}})
 	}
 
-	// Process cluster version
-	if showVersion {
-		cv, err := dp.getClusterVersion()
-		if err != nil {
+	if showPools {
+		fetches = append(fetches, fetchFunc{name: structs.SourceMachineConfigPools, optional: true, fetch: func(ctx context.Context) (err error) {
+			res.pools, err = dp.getMachineConfigPools(ctx)
 			return err
-		}
-		cd.Version = cv
+		}})
 	}
 
-	// Process cluster operators
-	if showOperators {
-		co, err := dp.getClusterOperators()
+	warnings, err := fetchAll(ctx, fetches)
+	if err != nil {
+		return nil, err
+	}
+	res.warnings = warnings
+	return res.merge()
+}
+
+// addNodeMetrics merges the current node metrics into the cluster data, or
+// records a warning if they are unavailable
+func (dp *nodePPCommand) addNodeMetrics(ctx context.Context, cd *structs.ClusterData) {
+	nodeMetrics, err := dp.getNodeMetrics(ctx)
+	if err != nil {
+		cd.AddWarning(structs.SourceNodeMetrics, err)
+		return
+	}
+	for i := range nodeMetrics.Items {
+		cd.AddNodeMetrics(&nodeMetrics.Items[i])
+	}
+}
+
+// getNodes returns the named node, or all nodes matching the label filter
+func (dp *nodePPCommand) getNodes(ctx context.Context, args []string) ([]v1.Node, error) {
+	if len(args) == 1 {
+		node, err := dp.kubeClient.CoreV1().Nodes().Get(ctx, args[0], metav1.GetOptions{})
 		if err != nil {
-			return err
+			return nil, err
 		}
-		cd.ClusterOperators = co
+		return []v1.Node{*node}, nil
 	}
+	nodes, err := dp.kubeClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{
+		LabelSelector: nodeLabels,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nodes.Items, nil
+}
 
-	// Render output
-	o := outputter.Outputter{
-		ShowUsage:   showUsage,
-		NodeMetrics: cd,
+// getPods returns the non-terminated pods scheduled to the named node, or to
+// any node if no node was named. Field selectors cannot match the set of nodes
+// selected by a label filter, so all pods are listed in one request and the
+// pods of other nodes are dropped when they are merged.
+func (dp *nodePPCommand) getPods(ctx context.Context, args []string) ([]v1.Pod, error) {
+	nodeSelector := fields.OneTermNotEqualSelector("spec.nodeName", "")
+	if len(args) == 1 {
+		nodeSelector = fields.OneTermEqualSelector("spec.nodeName", args[0])
 	}
-	o.Print()
-	if showKeys {
-		o.PrintKeys()
+	selector := fields.AndSelectors(
+		nodeSelector,
+		fields.OneTermNotEqualSelector("status.phase", string(v1.PodSucceeded)),
+		fields.OneTermNotEqualSelector("status.phase", string(v1.PodFailed)),
+	)
+	pods, err := dp.kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, err
 	}
-
-	return nil
+	return pods.Items, nil
 }
 
-func (dp *nodePPCommand) getMachine(name string) error {
-	machineClient, err := machinev1.NewForConfig(dp.restConfig)
-	_, err = machineClient.Machines(consts.MachineNamespace).Get(context.Background(), name, metav1.GetOptions{})
+func (dp *nodePPCommand) getMachineConfigPools(ctx context.Context) (*unstructured.UnstructuredList, error) {
+	pools, err := dp.dynamicClient.Resource(structs.MachineConfigPoolResource).List(ctx, metav1.ListOptions{})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return pools, nil
 }
 
-func (dp *nodePPCommand) getAllMachines() (*v1beta1.MachineList, error) {
-	machineClient, err := machinev1.NewForConfig(dp.restConfig)
-	machines, err := machineClient.Machines(consts.MachineNamespace).List(context.Background(), metav1.ListOptions{})
+func (dp *nodePPCommand) getAllMachines(ctx context.Context) (*v1beta1.MachineList, error) {
+	machines, err := dp.machineClient.MachineV1beta1().Machines(consts.MachineNamespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
 	return machines, nil
 }
 
-func (dp *nodePPCommand) getNodeMetrics() (*metricsv1beta1.NodeMetricsList, error) {
-	metricsClient, err := mcs.NewForConfig(dp.restConfig)
-	nmList, err := metricsClient.MetricsV1beta1().NodeMetricses().List(context.TODO(), metav1.ListOptions{})
+func (dp *nodePPCommand) getAllMachineSets(ctx context.Context) (*v1beta1.MachineSetList, error) {
+	machineSets, err := dp.machineClient.MachineV1beta1().MachineSets(consts.MachineNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return machineSets, nil
+}
+
+func (dp *nodePPCommand) getNodeMetrics(ctx context.Context) (*metricsv1beta1.NodeMetricsList, error) {
+	nmList, err := dp.metricsClient.MetricsV1beta1().NodeMetricses().List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
 	return nmList, nil
 }
 
-func (dp *nodePPCommand) getClusterVersion() (*oapi.ClusterVersion, error) {
-	cvClient, err := configclient.NewForConfig(dp.restConfig)
-	cv, err := cvClient.ConfigV1().ClusterVersions().Get(context.Background(), "version", metav1.GetOptions{})
+func (dp *nodePPCommand) getClusterVersion(ctx context.Context) (*oapi.ClusterVersion, error) {
+	cv, err := dp.configClient.ConfigV1().ClusterVersions().Get(ctx, "version", metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
 	return cv, nil
 }
 
-func (dp *nodePPCommand) getClusterOperators() (*oapi.ClusterOperatorList, error) {
-	cvClient, err := configclient.NewForConfig(dp.restConfig)
-	cos, err := cvClient.ConfigV1().ClusterOperators().List(context.Background(), metav1.ListOptions{})
+func (dp *nodePPCommand) getClusterOperators(ctx context.Context) (*oapi.ClusterOperatorList, error) {
+	cos, err := dp.configClient.ConfigV1().ClusterOperators().List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,400 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	goerrors "errors"
+	"strings"
+	"testing"
+
+	oapi "github.com/openshift/api/config/v1"
+	"github.com/openshift/api/machine/v1beta1"
+	configfake "github.com/openshift/client-go/config/clientset/versioned/fake"
+	machinefake "github.com/openshift/client-go/machine/clientset/versioned/fake"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+
+	"nodepp/internal/config"
+	"nodepp/internal/consts"
+	"nodepp/internal/outputter"
+	"nodepp/internal/structs"
+)
+
+// newTestCommand returns a command backed by fake clients holding the given
+// cluster objects
+func newTestCommand(kubeObjects []runtime.Object, machines []runtime.Object, metrics []metricsv1beta1.NodeMetrics, config []runtime.Object) (*nodePPCommand, *bytes.Buffer) {
+	out := new(bytes.Buffer)
+
+	// the fake metrics clientset cannot track NodeMetrics by resource name,
+	// so serve the list through a reactor instead
+	metricsClient := metricsfake.NewSimpleClientset()
+	metricsClient.PrependReactor("list", "nodes", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, &metricsv1beta1.NodeMetricsList{Items: metrics}, nil
+	})
+
+	return &nodePPCommand{
+		out:           out,
+		errOut:        new(bytes.Buffer),
+		kubeClient:    kubefake.NewSimpleClientset(kubeObjects...),
+		machineClient: machinefake.NewSimpleClientset(machines...),
+		metricsClient: metricsClient,
+		configClient:  configfake.NewSimpleClientset(config...),
+		dynamicClient: newDynamicClient(),
+	}, out
+}
+
+// newDynamicClient returns a fake dynamic client holding the given MachineConfigPools
+func newDynamicClient(pools ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{structs.MachineConfigPoolResource: "MachineConfigPoolList"},
+		pools...)
+}
+
+func testPool(name string, role string, machines int64, updated int64, degraded bool) *unstructured.Unstructured {
+	degradedStatus := "False"
+	if degraded {
+		degradedStatus = "True"
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "machineconfiguration.openshift.io/v1",
+		"kind":       "MachineConfigPool",
+		"metadata":   map[string]interface{}{"name": name},
+		"spec": map[string]interface{}{
+			"nodeSelector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{"node-role.kubernetes.io/" + role: ""},
+			},
+		},
+		"status": map[string]interface{}{
+			"machineCount":        machines,
+			"updatedMachineCount": updated,
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Degraded", "status": degradedStatus, "message": "render failed"},
+			},
+		},
+	}}
+}
+
+// setFlags sets the command flags for the duration of a test
+func setFlags(t *testing.T, usage, version, operators, pools bool) {
+	t.Helper()
+	oldUsage, oldVersion, oldOperators, oldPools := showUsage, showVersion, showOperators, showPools
+	showUsage, showVersion, showOperators, showPools = usage, version, operators, pools
+	t.Cleanup(func() {
+		showUsage, showVersion, showOperators, showPools = oldUsage, oldVersion, oldOperators, oldPools
+	})
+}
+
+func testNode(name string, role string, ready bool) *v1.Node {
+	status := v1.ConditionFalse
+	if ready {
+		status = v1.ConditionTrue
+	}
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      map[string]string{"node-role.kubernetes.io/" + role: ""},
+			Annotations: map[string]string{consts.Annotation_Machine: consts.MachineNamespace + "/" + name + "-machine"},
+		},
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: status}},
+			Allocatable: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("4"),
+				v1.ResourceMemory: resource.MustParse("16Gi"),
+				v1.ResourcePods:   resource.MustParse("110"),
+			},
+		},
+	}
+}
+
+func testPod(name string, nodeName string, phase v1.PodPhase) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       v1.PodSpec{NodeName: nodeName},
+		Status:     v1.PodStatus{Phase: phase},
+	}
+}
+
+func testMachine(name string, nodeName string, phase string) *v1beta1.Machine {
+	m := &v1beta1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: consts.MachineNamespace},
+		Status:     v1beta1.MachineStatus{Phase: &phase},
+	}
+	if nodeName != "" {
+		m.Status.NodeRef = &v1.ObjectReference{Kind: "Node", Name: nodeName}
+	}
+	return m
+}
+
+func testNodeMetrics(name string, cpu string, memory string) metricsv1beta1.NodeMetrics {
+	return metricsv1beta1.NodeMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Usage: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse(cpu),
+			v1.ResourceMemory: resource.MustParse(memory),
+		},
+	}
+}
+
+func TestCollectMergesNodesMachinesAndMetrics(t *testing.T) {
+	setFlags(t, true, true, true, true)
+	dp, _ := newTestCommand(
+		[]runtime.Object{
+			testNode("master-0", "master", true),
+			testNode("worker-0", "worker", false),
+			testPod("dns-1", "worker-0", v1.PodRunning),
+			testPod("installer-1", "worker-0", v1.PodSucceeded),
+			testPod("etcd-1", "master-0", v1.PodRunning),
+			testPod("router-1", "worker-0", v1.PodPending),
+		},
+		[]runtime.Object{
+			testMachine("master-0-machine", "master-0", "Running"),
+			testMachine("worker-0-machine", "worker-0", "Running"),
+			testMachine("worker-1-machine", "", "Provisioning"),
+		},
+		[]metricsv1beta1.NodeMetrics{testNodeMetrics("worker-0", "3800m", "8Gi")},
+		[]runtime.Object{
+			&oapi.ClusterVersion{ObjectMeta: metav1.ObjectMeta{Name: "version"}},
+			&oapi.ClusterOperator{
+				ObjectMeta: metav1.ObjectMeta{Name: "dns"},
+				Status: oapi.ClusterOperatorStatus{Conditions: []oapi.ClusterOperatorStatusCondition{
+					{Type: oapi.OperatorDegraded, Status: oapi.ConditionTrue},
+				}},
+			},
+		},
+	)
+
+	dp.dynamicClient = newDynamicClient(
+		testPool("master", "master", 1, 1, false),
+		testPool("worker", "worker", 2, 1, true),
+	)
+
+	cd, err := dp.collect(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cd.Nodes) != 3 {
+		t.Fatalf("expected 3 rows, got %d", len(cd.Nodes))
+	}
+	worker := cd.GetNode("worker-0")
+	if worker == nil || worker.Ready || worker.MachinePhase != "Running" {
+		t.Errorf("worker-0 was not merged with its machine: %+v", worker)
+	}
+	if worker.Cpu.Utilization.MilliValue() != 3800 {
+		t.Errorf("expected worker-0 cpu usage of 3800m, got %v", worker.Cpu.Utilization.String())
+	}
+	if worker.Pods.Utilization.Value() != 2 || worker.Pods.Allocatable.Value() != 110 {
+		t.Errorf("expected worker-0 to have 2/110 pods, got %v/%v", worker.Pods.Utilization.String(), worker.Pods.Allocatable.String())
+	}
+	if worker.Pool != "worker" || cd.GetNode("master-0").Pool != "master" {
+		t.Errorf("nodes were not assigned to their pools")
+	}
+	if len(cd.Pools) != 2 || !cd.Pools[1].Degraded || cd.Pools[1].UpdatedMachineCount != 1 {
+		t.Errorf("unexpected pool data: %+v", cd.Pools)
+	}
+	orphan := cd.GetNode("worker-1-machine")
+	if orphan == nil || orphan.NodeName != "" || orphan.MachinePhase != "Provisioning" {
+		t.Errorf("expected a row for the machine without a node: %+v", orphan)
+	}
+	if cd.Version == nil {
+		t.Errorf("expected cluster version to be collected")
+	}
+	if unhealthy := cd.UnhealthyOperators(); len(unhealthy) != 1 || unhealthy[0].Name != "dns" {
+		t.Errorf("expected dns to be reported as unhealthy, got %v", unhealthy)
+	}
+	if len(cd.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", cd.Warnings)
+	}
+}
+
+func TestCollectSingleNode(t *testing.T) {
+	setFlags(t, false, false, false, false)
+	dp, _ := newTestCommand(
+		[]runtime.Object{testNode("worker-0", "worker", true), testNode("worker-1", "worker", true)},
+		[]runtime.Object{testMachine("worker-1-machine", "worker-1", "Running")},
+		nil, nil,
+	)
+
+	cd, err := dp.collect(context.Background(), []string{"worker-0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cd.Nodes) != 1 || cd.Nodes[0].NodeName != "worker-0" {
+		t.Errorf("expected only worker-0, got %v", cd.Nodes)
+	}
+	if cd.Version != nil || cd.ClusterOperators != nil {
+		t.Errorf("cluster data should not be collected when disabled")
+	}
+}
+
+func TestCollectWithoutMachineAPI(t *testing.T) {
+	setFlags(t, true, false, false, false)
+	dp, _ := newTestCommand(
+		[]runtime.Object{testNode("worker-0", "worker", true)},
+		nil, nil, nil,
+	)
+	dp.machineClient.(*machinefake.Clientset).PrependReactor("list", "machines", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.NewNotFound(schema.GroupResource{Group: "machine.openshift.io", Resource: "machines"}, "")
+	})
+
+	cd, err := dp.collect(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("missing machine API should not be fatal: %v", err)
+	}
+	if len(cd.Nodes) != 1 {
+		t.Errorf("expected the node to still be listed")
+	}
+	if !cd.Unavailable(structs.SourceMachines) {
+		t.Errorf("expected machines to be reported as unavailable")
+	}
+}
+
+func TestRunWritesToGivenStream(t *testing.T) {
+	setFlags(t, false, false, false, false)
+	oldOutput := output
+	output = outputter.FormatJSON
+	t.Cleanup(func() { output = oldOutput })
+
+	dp, out := newTestCommand(
+		[]runtime.Object{testNode("worker-0", "worker", true)},
+		nil, nil, nil,
+	)
+	if err := dp.run(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := new(outputter.Document)
+	if err := json.Unmarshal(out.Bytes(), doc); err != nil {
+		t.Fatalf("output is not a JSON document: %v", err)
+	}
+	if doc.APIVersion != outputter.DocumentAPIVersion || len(doc.Nodes) != 1 {
+		t.Errorf("unexpected document: %+v", doc)
+	}
+}
+
+func TestCheckExitsWithSeverity(t *testing.T) {
+	setFlags(t, false, false, false, false)
+	oldCheck, oldOutput := check, output
+	check, output = true, outputter.FormatTable
+	t.Cleanup(func() { check, output = oldCheck, oldOutput })
+
+	dp, out := newTestCommand(
+		[]runtime.Object{testNode("worker-0", "worker", true), testNode("worker-1", "worker", false)},
+		nil, nil, nil,
+	)
+	err := dp.run(context.Background(), nil)
+
+	var exitErr *ExitError
+	if !goerrors.As(err, &exitErr) || exitErr.Code != ExitCritical {
+		t.Fatalf("expected a critical exit code, got %v", err)
+	}
+	if !strings.Contains(out.String(), "node worker-1 is not ready") {
+		t.Errorf("expected the reason to be printed, got %q", out.String())
+	}
+}
+
+func TestOldOperatorsShorthandHint(t *testing.T) {
+	ccmd := NewNodePPCommand(genericclioptions.IOStreams{Out: new(bytes.Buffer), ErrOut: new(bytes.Buffer)})
+	ccmd.SetArgs([]string{"-o=false", "--" + config.ConfigFile, ""})
+	ccmd.SilenceErrors = true
+
+	err := ccmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "--show-operators=false") {
+		t.Errorf("expected a hint to use --show-operators, got %v", err)
+	}
+}
+
+func TestCheckAndWatchConflictNeedsNoCluster(t *testing.T) {
+	oldCheck, oldWatch, oldOutput := check, watch, output
+	check, watch, output = true, true, outputter.FormatTable
+	t.Cleanup(func() { check, watch, output = oldCheck, oldWatch, oldOutput })
+
+	// without a client factory, setting up clients would panic
+	dp := &nodePPCommand{out: new(bytes.Buffer), errOut: new(bytes.Buffer)}
+	err := dp.run(context.Background(), nil)
+	if err == nil || !strings.Contains(err.Error(), "cannot be used with") {
+		t.Errorf("expected the flag conflict to be reported before connecting, got %v", err)
+	}
+}
+
+func TestOperatorsSubcommandNames(t *testing.T) {
+	ccmd := NewNodePPCommand(genericclioptions.IOStreams{Out: new(bytes.Buffer), ErrOut: new(bytes.Buffer)})
+	ccmd.InitDefaultCompletionCmd()
+	for _, sub := range ccmd.Commands() {
+		if sub.Name() == "completion" {
+			t.Errorf("expected no completion command to take a node name")
+		}
+	}
+
+	// a node named operators is still reachable after --
+	found, args, err := ccmd.Find([]string{"--", "operators"})
+	if err != nil || found != ccmd || len(args) != 2 || args[1] != "operators" {
+		t.Errorf("expected -- operators to name a node, got %s %v %v", found.Name(), args, err)
+	}
+}
+
+func TestColumnsFlagKeepsCommas(t *testing.T) {
+	oldColumns := customColumns
+	t.Cleanup(func() { customColumns = oldColumns })
+
+	ccmd := NewNodePPCommand(genericclioptions.IOStreams{Out: new(bytes.Buffer), ErrOut: new(bytes.Buffer)})
+	err := ccmd.ParseFlags([]string{"--columns", "NAMES:{.metadata.name,.metadata.uid}", "--columns", "ZONE:.metadata.labels.zone"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(customColumns) != 2 || customColumns[0] != "NAMES:{.metadata.name,.metadata.uid}" {
+		t.Errorf("expected one column per flag, got %q", customColumns)
+	}
+}
+
+func TestLabelFilterListsPodsOnce(t *testing.T) {
+	setFlags(t, true, false, false, false)
+	oldLabels := nodeLabels
+	nodeLabels = "node-role.kubernetes.io/infra"
+	t.Cleanup(func() { nodeLabels = oldLabels })
+
+	dp, _ := newTestCommand([]runtime.Object{
+		testNode("infra-0", "infra", true),
+		testNode("worker-0", "worker", true),
+		testPod("router", "infra-0", v1.PodRunning),
+		testPod("app", "worker-0", v1.PodRunning),
+	}, nil, nil, nil)
+	cd, err := dp.collect(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var nodeLists, podLists int
+	for _, action := range dp.kubeClient.(*kubefake.Clientset).Actions() {
+		if _, ok := action.(clienttesting.ListAction); !ok {
+			continue
+		}
+		switch action.GetResource().Resource {
+		case "nodes":
+			nodeLists++
+		case "pods":
+			podLists++
+		}
+	}
+	if nodeLists != 1 || podLists != 1 {
+		t.Errorf("expected one node list and one pod list, got %d and %d", nodeLists, podLists)
+	}
+	if len(cd.Nodes) != 1 || cd.GetNode("infra-0") == nil {
+		t.Fatalf("expected only infra-0, got %d nodes", len(cd.Nodes))
+	}
+	if pods := cd.GetNode("infra-0").Pods.Utilization.Value(); pods != 1 {
+		t.Errorf("expected infra-0 to have 1 pod, got %d", pods)
+	}
+}
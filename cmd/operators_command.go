@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"nodepp/internal/config"
+	"nodepp/internal/outputter"
+	"nodepp/internal/structs"
+)
+
+const operatorsDescription = `
+Show every ClusterOperator with its version, conditions, the time since its
+last transition and the message of its most significant condition. Operators
+that have been progressing for a long time or are not upgradeable are
+highlighted, as they block cluster upgrades.
+`
+
+func newOperatorsCommand(dp *nodePPCommand) *cobra.Command {
+	return &cobra.Command{
+		Use:          "operators",
+		Short:        "Show the status of every cluster operator",
+		Long:         operatorsDescription,
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return dp.runOperators(cmd.Context())
+		},
+	}
+}
+
+func (dp *nodePPCommand) runOperators(ctx context.Context) error {
+	if watch {
+		return fmt.Errorf("--%s is not supported by the operators view", config.Watch)
+	}
+	opts, err := dp.renderOptions()
+	if err != nil {
+		return err
+	}
+	renderer, err := outputter.NewOperatorsRenderer(output, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := dp.setupClients(); err != nil {
+		return err
+	}
+
+	operators, err := dp.getClusterOperators(ctx)
+	if err != nil {
+		return err
+	}
+	return renderer.Render(&structs.ClusterData{ClusterOperators: operators}, dp.out)
+}
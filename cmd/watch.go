@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"nodepp/internal/collector"
+	"nodepp/internal/outputter"
+	"nodepp/internal/structs"
+)
+
+// watch keeps an informer-backed view of the cluster and renders it every
+// interval until interrupted. Node metrics cannot be watched, so they are
+// re-listed on each refresh.
+func (dp *nodePPCommand) watch(ctx context.Context, args []string, renderer outputter.Renderer) error {
+	if watchInterval <= 0 {
+		return fmt.Errorf("watch interval must be positive, got %v", watchInterval)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	opts := collector.Options{
+		NodeLabels:       nodeLabels,
+		WatchVersion:     showVersion || showUpgrade,
+		WatchOperators:   showOperators,
+		WatchPods:        showUsage,
+		WatchPools:       showPools,
+		WatchMachineSets: groupBy == structs.GroupByMachineSet,
+	}
+	if len(args) == 1 {
+		opts.NodeName = args[0]
+	}
+	col := collector.New(dp.kubeClient, dp.machineClient, dp.configClient, dp.dynamicClient, opts)
+	if err := col.Start(ctx); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	for {
+		dp.refresh(ctx, col, renderer)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh renders a single snapshot of the collector in watch mode
+func (dp *nodePPCommand) refresh(ctx context.Context, col *collector.Collector, renderer outputter.Renderer) {
+	cd := col.Snapshot()
+	if showUsage {
+		dp.addNodeMetrics(ctx, cd)
+	}
+	dp.prepare(cd)
+
+	switch output {
+	case outputter.FormatTable, outputter.FormatWide:
+		if dp.isTerminal() {
+			outputter.ClearScreen(dp.out)
+		} else {
+			// keep escape codes out of logs and pipes
+			fmt.Fprintln(dp.out)
+		}
+		fmt.Fprintf(dp.out, "Every %v: %s\n\n", watchInterval, time.Now().Format(time.RFC1123))
+	case outputter.FormatYAML:
+		fmt.Fprintln(dp.out, "---")
+	}
+	if err := renderer.Render(cd, dp.out); err != nil {
+		fmt.Fprintf(dp.errOut, "error rendering: %v\n", err)
+	}
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"nodepp/internal/collector"
+	"nodepp/internal/outputter"
+)
+
+// startTestCollector returns a running collector over the command's fake clients
+func startTestCollector(t *testing.T, dp *nodePPCommand) *collector.Collector {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	col := collector.New(dp.kubeClient, dp.machineClient, dp.configClient, dp.dynamicClient, collector.Options{})
+	if err := col.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return col
+}
+
+func TestRefreshDoesNotClearNonTerminals(t *testing.T) {
+	setFlags(t, false, false, false, false)
+	oldOutput := output
+	output = outputter.FormatTable
+	t.Cleanup(func() { output = oldOutput })
+
+	dp, out := newTestCommand([]runtime.Object{testNode("worker-0", "worker", true)}, nil, nil, nil)
+	renderer, err := outputter.NewRenderer(output, outputter.Options{NoColor: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	col := startTestCollector(t, dp)
+	dp.refresh(context.Background(), col, renderer)
+	dp.refresh(context.Background(), col, renderer)
+
+	if strings.Contains(out.String(), "\033[") {
+		t.Errorf("expected no escape codes when not writing to a terminal, got %q", out.String())
+	}
+	if strings.Count(out.String(), "Every ") != 2 {
+		t.Errorf("expected both refreshes to be written, got %q", out.String())
+	}
+}
+
+func TestRefreshWritesNewlineDelimitedJSON(t *testing.T) {
+	setFlags(t, false, false, false, false)
+	oldOutput := output
+	output = outputter.FormatJSON
+	t.Cleanup(func() { output = oldOutput })
+
+	dp, out := newTestCommand([]runtime.Object{testNode("worker-0", "worker", true)}, nil, nil, nil)
+	renderer, err := outputter.NewRenderer(output, outputter.Options{Stream: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	col := startTestCollector(t, dp)
+	dp.refresh(context.Background(), col, renderer)
+	dp.refresh(context.Background(), col, renderer)
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected a line per refresh, got %q", out.String())
+	}
+	for _, line := range lines {
+		doc := new(outputter.Document)
+		if err := json.Unmarshal([]byte(line), doc); err != nil || len(doc.Nodes) != 1 {
+			t.Errorf("expected each line to hold a document, got %q: %v", line, err)
+		}
+	}
+}
+
+func TestWatchRejectsTemplates(t *testing.T) {
+	oldWatch, oldOutput := watch, output
+	watch, output = true, outputter.FormatGoTemplate+"={{len .Nodes}}"
+	t.Cleanup(func() { watch, output = oldWatch, oldOutput })
+
+	dp := &nodePPCommand{out: new(bytes.Buffer), errOut: new(bytes.Buffer)}
+	err := dp.run(context.Background(), nil)
+	if err == nil || !strings.Contains(err.Error(), "cannot be used with --watch") {
+		t.Errorf("expected go-template output to be rejected, got %v", err)
+	}
+}
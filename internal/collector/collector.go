@@ -0,0 +1,528 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	oapi "github.com/openshift/api/config/v1"
+	"github.com/openshift/api/machine/v1beta1"
+	configclient "github.com/openshift/client-go/config/clientset/versioned"
+	configinformers "github.com/openshift/client-go/config/informers/externalversions"
+	machineclient "github.com/openshift/client-go/machine/clientset/versioned"
+	machineinformers "github.com/openshift/client-go/machine/informers/externalversions"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"nodepp/internal/consts"
+	"nodepp/internal/structs"
+)
+
+// Options controls which resources the collector watches
+type Options struct {
+	// NodeName restricts the collector to a single node
+	NodeName string
+	// NodeLabels is a label selector applied to nodes
+	NodeLabels string
+	// WatchVersion enables watching the ClusterVersion
+	WatchVersion bool
+	// WatchOperators enables watching ClusterOperators
+	WatchOperators bool
+	// WatchPods enables watching the pods scheduled to nodes
+	WatchPods bool
+	// WatchPools enables watching MachineConfigPools
+	WatchPools bool
+	// WatchMachineSets enables watching MachineSets
+	WatchMachineSets bool
+	// Resync is the shared informer resync period
+	Resync time.Duration
+}
+
+// Collector keeps the merged node and machine view up to date from shared
+// informer events, so that repeated reads do not need to hit the API server.
+type Collector struct {
+	opts Options
+
+	kubeClient    kubernetes.Interface
+	machineClient machineclient.Interface
+	configClient  configclient.Interface
+	dynamicClient dynamic.Interface
+
+	poolLister cache.GenericLister
+
+	kubeFactory    informers.SharedInformerFactory
+	podFactory     informers.SharedInformerFactory
+	machineFactory machineinformers.SharedInformerFactory
+	configFactory  configinformers.SharedInformerFactory
+	dynamicFactory dynamicinformer.DynamicSharedInformerFactory
+
+	mu               sync.RWMutex
+	nodes            map[string]*structs.NodeData
+	machines         map[string]*structs.NodeData
+	machineSets      map[string]*structs.MachineSetData
+	version          *oapi.ClusterVersion
+	clusterOperators map[string]*oapi.ClusterOperator
+	warnings         []structs.Warning
+
+	// pods holds the usage of each pod by node and pod key, and podUsage
+	// the sum for each node, so that snapshots copy a summary per node
+	// rather than every pod. watchingPods is set once pods are watched.
+	pods         map[string]map[string]*structs.PodUsage
+	podNodes     map[string]string
+	podUsage     map[string]*structs.PodUsage
+	watchingPods bool
+}
+
+// New creates a collector for the given clients. Call Start to begin
+// receiving events.
+func New(kubeClient kubernetes.Interface, machineClient machineclient.Interface, configClient configclient.Interface, dynamicClient dynamic.Interface, opts Options) *Collector {
+	c := &Collector{
+		opts:             opts,
+		kubeClient:       kubeClient,
+		machineClient:    machineClient,
+		configClient:     configClient,
+		dynamicClient:    dynamicClient,
+		nodes:            make(map[string]*structs.NodeData),
+		machines:         make(map[string]*structs.NodeData),
+		machineSets:      make(map[string]*structs.MachineSetData),
+		clusterOperators: make(map[string]*oapi.ClusterOperator),
+		pods:             make(map[string]map[string]*structs.PodUsage),
+		podNodes:         make(map[string]string),
+		podUsage:         make(map[string]*structs.PodUsage),
+	}
+
+	c.kubeFactory = informers.NewSharedInformerFactoryWithOptions(kubeClient, opts.Resync,
+		informers.WithTweakListOptions(func(lo *metav1.ListOptions) {
+			lo.LabelSelector = opts.NodeLabels
+			if opts.NodeName != "" {
+				lo.FieldSelector = fields.OneTermEqualSelector("metadata.name", opts.NodeName).String()
+			}
+		}))
+	c.podFactory = informers.NewSharedInformerFactoryWithOptions(kubeClient, opts.Resync,
+		informers.WithTweakListOptions(func(lo *metav1.ListOptions) {
+			nodeSelector := fields.OneTermNotEqualSelector("spec.nodeName", "")
+			if opts.NodeName != "" {
+				nodeSelector = fields.OneTermEqualSelector("spec.nodeName", opts.NodeName)
+			}
+			lo.FieldSelector = fields.AndSelectors(
+				nodeSelector,
+				fields.OneTermNotEqualSelector("status.phase", string(v1.PodSucceeded)),
+				fields.OneTermNotEqualSelector("status.phase", string(v1.PodFailed)),
+			).String()
+		}))
+	c.machineFactory = machineinformers.NewSharedInformerFactoryWithOptions(machineClient, opts.Resync,
+		machineinformers.WithNamespace(consts.MachineNamespace))
+	c.configFactory = configinformers.NewSharedInformerFactory(configClient, opts.Resync)
+	c.dynamicFactory = dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, opts.Resync)
+
+	return c
+}
+
+// Start registers informers for every available data source, runs them until
+// the context is cancelled, and blocks until their caches have synced.
+// Optional sources that cannot be listed, such as machines on clusters
+// without the Machine API, are skipped and reported as warnings.
+func (c *Collector) Start(ctx context.Context) error {
+	handlers := map[cache.SharedIndexInformer]cache.ResourceEventHandlerFuncs{
+		c.kubeFactory.Core().V1().Nodes().Informer(): {
+			AddFunc:    c.onNode,
+			UpdateFunc: func(_, obj interface{}) { c.onNode(obj) },
+			DeleteFunc: c.onNodeDelete,
+		},
+	}
+	probe := metav1.ListOptions{Limit: 1}
+	if _, err := c.machineClient.MachineV1beta1().Machines(consts.MachineNamespace).List(ctx, probe); err != nil {
+		c.addWarning(structs.SourceMachines, err)
+	} else {
+		handlers[c.machineFactory.Machine().V1beta1().Machines().Informer()] = cache.ResourceEventHandlerFuncs{
+			AddFunc:    c.onMachine,
+			UpdateFunc: func(_, obj interface{}) { c.onMachine(obj) },
+			DeleteFunc: c.onMachineDelete,
+		}
+	}
+	if c.opts.WatchMachineSets {
+		if _, err := c.machineClient.MachineV1beta1().MachineSets(consts.MachineNamespace).List(ctx, probe); err != nil {
+			c.addWarning(structs.SourceMachineSets, err)
+		} else {
+			handlers[c.machineFactory.Machine().V1beta1().MachineSets().Informer()] = cache.ResourceEventHandlerFuncs{
+				AddFunc:    c.onMachineSet,
+				UpdateFunc: func(_, obj interface{}) { c.onMachineSet(obj) },
+				DeleteFunc: c.onMachineSetDelete,
+			}
+		}
+	}
+	if c.opts.WatchVersion {
+		if _, err := c.configClient.ConfigV1().ClusterVersions().List(ctx, probe); err != nil {
+			c.addWarning(structs.SourceClusterVersion, err)
+		} else {
+			handlers[c.configFactory.Config().V1().ClusterVersions().Informer()] = cache.ResourceEventHandlerFuncs{
+				AddFunc:    c.onClusterVersion,
+				UpdateFunc: func(_, obj interface{}) { c.onClusterVersion(obj) },
+			}
+		}
+	}
+	if c.opts.WatchOperators {
+		if _, err := c.configClient.ConfigV1().ClusterOperators().List(ctx, probe); err != nil {
+			c.addWarning(structs.SourceClusterOperators, err)
+		} else {
+			handlers[c.configFactory.Config().V1().ClusterOperators().Informer()] = cache.ResourceEventHandlerFuncs{
+				AddFunc:    c.onClusterOperator,
+				UpdateFunc: func(_, obj interface{}) { c.onClusterOperator(obj) },
+				DeleteFunc: c.onClusterOperatorDelete,
+			}
+		}
+	}
+	if c.opts.WatchPods {
+		if _, err := c.kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(ctx, probe); err != nil {
+			c.addWarning(structs.SourcePods, err)
+		} else {
+			informer := c.podFactory.Core().V1().Pods().Informer()
+			if err := informer.SetTransform(slimPod); err != nil {
+				return err
+			}
+			handlers[informer] = cache.ResourceEventHandlerFuncs{
+				AddFunc:    c.onPod,
+				UpdateFunc: func(_, obj interface{}) { c.onPod(obj) },
+				DeleteFunc: c.onPodDelete,
+			}
+			c.watchingPods = true
+		}
+	}
+	if c.opts.WatchPools {
+		if _, err := c.dynamicClient.Resource(structs.MachineConfigPoolResource).List(ctx, probe); err != nil {
+			c.addWarning(structs.SourceMachineConfigPools, err)
+		} else {
+			// pools are read straight from the informer cache when snapshotting
+			c.poolLister = c.dynamicFactory.ForResource(structs.MachineConfigPoolResource).Lister()
+		}
+	}
+	for informer, handler := range handlers {
+		if _, err := informer.AddEventHandler(handler); err != nil {
+			return err
+		}
+	}
+
+	c.kubeFactory.Start(ctx.Done())
+	c.podFactory.Start(ctx.Done())
+	c.machineFactory.Start(ctx.Done())
+	c.configFactory.Start(ctx.Done())
+	c.dynamicFactory.Start(ctx.Done())
+
+	synced := make(map[string]bool)
+	for t, ok := range c.kubeFactory.WaitForCacheSync(ctx.Done()) {
+		synced[t.String()] = ok
+	}
+	for t, ok := range c.podFactory.WaitForCacheSync(ctx.Done()) {
+		synced[t.String()] = ok
+	}
+	for t, ok := range c.machineFactory.WaitForCacheSync(ctx.Done()) {
+		synced[t.String()] = ok
+	}
+	for t, ok := range c.configFactory.WaitForCacheSync(ctx.Done()) {
+		synced[t.String()] = ok
+	}
+	for gvr, ok := range c.dynamicFactory.WaitForCacheSync(ctx.Done()) {
+		synced[gvr.String()] = ok
+	}
+	for t, ok := range synced {
+		if !ok {
+			return fmt.Errorf("failed to sync informer cache for %s", t)
+		}
+	}
+	return nil
+}
+
+// Snapshot returns a copy of the current merged cluster data. The result is
+// safe to modify, e.g. to merge in node metrics.
+func (c *Collector) Snapshot() *structs.ClusterData {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cd := new(structs.ClusterData)
+	cd.Warnings = append(cd.Warnings, c.warnings...)
+	cd.Nodes = make([]*structs.NodeData, 0, len(c.nodes))
+	for _, name := range sortedKeys(c.nodes) {
+		cd.Nodes = append(cd.Nodes, c.nodes[name].DeepCopy())
+	}
+	for _, name := range sortedKeys(c.machines) {
+		cd.AddMachine(c.machines[name].DeepCopy())
+	}
+
+	if c.opts.WatchMachineSets && !cd.Unavailable(structs.SourceMachineSets) {
+		machineSets := make([]*structs.MachineSetData, 0, len(c.machineSets))
+		for _, ms := range c.machineSets {
+			msCopy := *ms
+			machineSets = append(machineSets, &msCopy)
+		}
+		cd.AddMachineSets(machineSets)
+	}
+
+	if c.watchingPods {
+		usage := make(map[string]*structs.PodUsage, len(c.podUsage))
+		for nodeName, nodeUsage := range c.podUsage {
+			usage[nodeName] = nodeUsage.DeepCopy()
+		}
+		cd.AddPodUsage(usage)
+	}
+
+	if c.poolLister != nil {
+		objs, err := c.poolLister.List(labels.Everything())
+		if err == nil {
+			pools := make([]*structs.PoolData, 0, len(objs))
+			for _, obj := range objs {
+				u, ok := obj.(*unstructured.Unstructured)
+				if !ok {
+					continue
+				}
+				poolData, err := structs.NewFromMachineConfigPool(u)
+				if err != nil {
+					continue
+				}
+				pools = append(pools, poolData)
+			}
+			sort.Slice(pools, func(i, j int) bool { return pools[i].Name < pools[j].Name })
+			cd.AddPools(pools)
+		}
+	}
+
+	if c.version != nil {
+		cd.Version = c.version.DeepCopy()
+	}
+	if c.opts.WatchOperators && !cd.Unavailable(structs.SourceClusterOperators) {
+		cd.ClusterOperators = &oapi.ClusterOperatorList{}
+		names := make([]string, 0, len(c.clusterOperators))
+		for name := range c.clusterOperators {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			cd.ClusterOperators.Items = append(cd.ClusterOperators.Items, *c.clusterOperators[name].DeepCopy())
+		}
+	}
+	return cd
+}
+
+func (c *Collector) addWarning(source string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.warnings = append(c.warnings, structs.NewWarning(source, err))
+}
+
+func (c *Collector) onNode(obj interface{}) {
+	node, ok := obj.(*v1.Node)
+	if !ok {
+		return
+	}
+	nodeData, err := structs.NewFromNode(node)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nodes[node.Name] = nodeData
+}
+
+func (c *Collector) onNodeDelete(obj interface{}) {
+	name, ok := deletedName(obj)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.nodes, name)
+}
+
+func (c *Collector) onMachine(obj interface{}) {
+	machine, ok := obj.(*v1beta1.Machine)
+	if !ok {
+		return
+	}
+	nodeData, err := structs.NewFromMachine(machine)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.machines[machine.Name] = nodeData
+}
+
+func (c *Collector) onMachineDelete(obj interface{}) {
+	name, ok := deletedName(obj)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.machines, name)
+}
+
+func (c *Collector) onMachineSet(obj interface{}) {
+	ms, ok := obj.(*v1beta1.MachineSet)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.machineSets[ms.Name] = structs.NewFromMachineSet(ms)
+}
+
+func (c *Collector) onMachineSetDelete(obj interface{}) {
+	name, ok := deletedName(obj)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.machineSets, name)
+}
+
+// slimPod strips a pod down to the fields needed to count its usage, so the
+// informer cache does not hold every pod in full
+func slimPod(obj interface{}) (interface{}, error) {
+	pod, ok := obj.(*v1.Pod)
+	if !ok {
+		return obj, nil
+	}
+	slim := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            pod.Name,
+			Namespace:       pod.Namespace,
+			UID:             pod.UID,
+			ResourceVersion: pod.ResourceVersion,
+		},
+		Spec: v1.PodSpec{
+			NodeName: pod.Spec.NodeName,
+			Overhead: pod.Spec.Overhead,
+		},
+		Status: v1.PodStatus{Phase: pod.Status.Phase},
+	}
+	for _, container := range pod.Spec.InitContainers {
+		slim.Spec.InitContainers = append(slim.Spec.InitContainers, v1.Container{Name: container.Name, Resources: container.Resources})
+	}
+	for _, container := range pod.Spec.Containers {
+		slim.Spec.Containers = append(slim.Spec.Containers, v1.Container{Name: container.Name, Resources: container.Resources})
+	}
+	return slim, nil
+}
+
+func (c *Collector) onPod(obj interface{}) {
+	pod, ok := obj.(*v1.Pod)
+	if !ok {
+		return
+	}
+	key, err := cache.MetaNamespaceKeyFunc(pod)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removePod(key)
+	if pod.Spec.NodeName == "" || structs.PodTerminated(pod) {
+		return
+	}
+	if c.pods[pod.Spec.NodeName] == nil {
+		c.pods[pod.Spec.NodeName] = make(map[string]*structs.PodUsage)
+	}
+	c.pods[pod.Spec.NodeName][key] = structs.NewPodUsage(pod)
+	c.podNodes[key] = pod.Spec.NodeName
+	c.sumPodUsage(pod.Spec.NodeName)
+}
+
+func (c *Collector) onPodDelete(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removePod(key)
+}
+
+// removePod forgets a pod's usage, e.g. before recording its new state. The
+// caller must hold the lock.
+func (c *Collector) removePod(key string) {
+	nodeName, ok := c.podNodes[key]
+	if !ok {
+		return
+	}
+	delete(c.podNodes, key)
+	delete(c.pods[nodeName], key)
+	c.sumPodUsage(nodeName)
+}
+
+// sumPodUsage recalculates the usage of a node from its pods. The caller must
+// hold the lock.
+func (c *Collector) sumPodUsage(nodeName string) {
+	if len(c.pods[nodeName]) == 0 {
+		delete(c.pods, nodeName)
+		delete(c.podUsage, nodeName)
+		return
+	}
+	usage := new(structs.PodUsage)
+	for _, podUsage := range c.pods[nodeName] {
+		usage.Add(podUsage)
+	}
+	c.podUsage[nodeName] = usage
+}
+
+func (c *Collector) onClusterVersion(obj interface{}) {
+	cv, ok := obj.(*oapi.ClusterVersion)
+	if !ok || cv.Name != "version" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version = cv
+}
+
+func (c *Collector) onClusterOperator(obj interface{}) {
+	co, ok := obj.(*oapi.ClusterOperator)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clusterOperators[co.Name] = co
+}
+
+func (c *Collector) onClusterOperatorDelete(obj interface{}) {
+	name, ok := deletedName(obj)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.clusterOperators, name)
+}
+
+// deletedName returns the name of a deleted object, unwrapping the tombstone
+// passed when the informer missed the delete event
+func deletedName(obj interface{}) (string, bool) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	o, ok := obj.(metav1.Object)
+	if !ok {
+		return "", false
+	}
+	return o.GetName(), true
+}
+
+func sortedKeys(m map[string]*structs.NodeData) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
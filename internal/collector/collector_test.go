@@ -0,0 +1,169 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/api/machine/v1beta1"
+	configfake "github.com/openshift/client-go/config/clientset/versioned/fake"
+	machinefake "github.com/openshift/client-go/machine/clientset/versioned/fake"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"nodepp/internal/consts"
+	"nodepp/internal/structs"
+)
+
+func TestCollectorTracksEvents(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "node-a",
+			Labels: map[string]string{"node-role.kubernetes.io/worker": ""},
+		},
+	})
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{structs.MachineConfigPoolResource: "MachineConfigPoolList"},
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "machineconfiguration.openshift.io/v1",
+			"kind":       "MachineConfigPool",
+			"metadata":   map[string]interface{}{"name": "worker"},
+			"spec": map[string]interface{}{
+				"nodeSelector": map[string]interface{}{
+					"matchLabels": map[string]interface{}{"node-role.kubernetes.io/worker": ""},
+				},
+			},
+			"status": map[string]interface{}{"machineCount": int64(1), "updatedMachineCount": int64(1)},
+		}},
+	)
+	machineClient := machinefake.NewSimpleClientset(
+		&v1beta1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: "machine-a", Namespace: consts.MachineNamespace},
+			Status: v1beta1.MachineStatus{
+				NodeRef: &v1.ObjectReference{Kind: "Node", Name: "node-a"},
+				Phase:   strPtr("Running"),
+			},
+		},
+		&v1beta1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: "machine-b", Namespace: consts.MachineNamespace},
+			Status:     v1beta1.MachineStatus{Phase: strPtr("Provisioning")},
+		},
+	)
+
+	col := New(kubeClient, machineClient, configfake.NewSimpleClientset(), dynamicClient, Options{WatchPools: true})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := col.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	cd := col.Snapshot()
+	if len(cd.Nodes) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(cd.Nodes))
+	}
+	if n := cd.GetNode("node-a"); n == nil || n.MachinePhase != "Running" {
+		t.Errorf("expected node-a to be merged with its running machine")
+	}
+	if n := cd.GetNode("node-a"); n.Pool != "worker" || len(cd.Pools) != 1 || cd.Pools[0].UpdatedMachineCount != 1 {
+		t.Errorf("expected node-a to be assigned to the worker pool")
+	}
+	if n := cd.GetNode("machine-b"); n == nil || n.NodeName != "" {
+		t.Errorf("expected machine-b to be listed without a node")
+	}
+
+	// snapshots must not share state with the collector
+	cd.GetNode("node-a").MachinePhase = "Mutated"
+	if n := col.Snapshot().GetNode("node-a"); n.MachinePhase != "Running" {
+		t.Errorf("snapshot modification leaked into the collector")
+	}
+
+	err := kubeClient.CoreV1().Nodes().Delete(ctx, "node-a", metav1.DeleteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		return col.Snapshot().GetNode("node-a") == nil
+	})
+	if n := col.Snapshot().GetNode("machine-b"); n == nil {
+		t.Errorf("expected machine-b to remain after node-a was deleted")
+	}
+}
+
+func TestCollectorTracksPodUsage(t *testing.T) {
+	pod := func(name string, cpu string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: v1.PodSpec{
+				NodeName: "node-a",
+				Containers: []v1.Container{{
+					Name: "app",
+					Resources: v1.ResourceRequirements{
+						Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu)},
+					},
+				}},
+			},
+			Status: v1.PodStatus{Phase: v1.PodRunning},
+		}
+	}
+	kubeClient := kubefake.NewSimpleClientset(
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a"}},
+		pod("pod-a", "100m"),
+		pod("pod-b", "200m"),
+	)
+
+	col := New(kubeClient, machinefake.NewSimpleClientset(), configfake.NewSimpleClientset(), dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()), Options{WatchPods: true})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := col.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	usage := func() (int64, string) {
+		n := col.Snapshot().GetNode("node-a")
+		return n.Pods.Utilization.Value(), n.Cpu.Requests.String()
+	}
+	if count, cpu := usage(); count != 2 || cpu != "300m" {
+		t.Errorf("expected 2 pods requesting 300m, got %d requesting %s", count, cpu)
+	}
+
+	// terminated pods no longer count towards the node
+	done := pod("pod-a", "100m")
+	done.Status.Phase = v1.PodSucceeded
+	if _, err := kubeClient.CoreV1().Pods("default").UpdateStatus(ctx, done, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		count, cpu := usage()
+		return count == 1 && cpu == "200m"
+	})
+
+	if err := kubeClient.CoreV1().Pods("default").Delete(ctx, "pod-b", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		count, _ := usage()
+		return count == 0
+	})
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("condition not met before deadline")
+}
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -11,9 +11,55 @@ const (
 	// ShowVersion controls whether cluster version data is displayed
 	ShowVersion string = "show-version"
 
+	// ShowUpgradeDetails controls whether the detailed cluster version panel is displayed
+	ShowUpgradeDetails string = "upgrade-details"
+
 	// ShowOperators controls whether cluster operator data is displayed
 	ShowOperators string = "show-operators"
 
+	// ShowPools controls whether MachineConfigPool data is displayed
+	ShowPools string = "show-pools"
+
+	// ShowTopology controls whether the zone and instance type of nodes are displayed
+	ShowTopology string = "show-topology"
+
 	// NodeLabels controls filtering based on node labels
 	NodeLabels string = "node-labels"
+
+	// Only controls which row states are displayed
+	Only string = "only"
+
+	// SortBy controls the order rows are displayed in
+	SortBy string = "sort-by"
+
+	// GroupBy controls the field rows are grouped by
+	GroupBy string = "group-by"
+
+	// Columns controls the custom columns added to the table
+	Columns string = "columns"
+
+	// Output controls the format that results are printed in
+	Output string = "output"
+
+	// Watch controls whether output is continuously refreshed
+	Watch string = "watch"
+
+	// WatchInterval controls how often output is refreshed when watching
+	WatchInterval string = "watch-interval"
+
+	// Check controls whether the cluster is evaluated against health rules
+	// instead of being displayed
+	Check string = "check"
+
+	// Theme controls the symbols and colours used to mark states
+	Theme string = "theme"
+
+	// NoEmoji controls whether states are marked with text codes instead of emoji
+	NoEmoji string = "no-emoji"
+
+	// Plain controls whether output is plain ASCII text without emoji or colours
+	Plain string = "plain"
+
+	// ConfigFile controls which config file thresholds and flag defaults are read from
+	ConfigFile string = "config"
 )
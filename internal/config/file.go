@@ -0,0 +1,187 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultHotThreshold is the utilization percentage above which a resource is
+// hot, unless the config file says otherwise
+const DefaultHotThreshold = 90
+
+// Thresholds are the utilization percentages above which a node's CPU,
+// memory and pods are highlighted as hot. Zero values are unset.
+type Thresholds struct {
+	CPU    float64 `json:"cpu,omitempty"`
+	Memory float64 `json:"memory,omitempty"`
+	Pods   float64 `json:"pods,omitempty"`
+}
+
+// ThresholdConfig holds the cluster-wide thresholds and overrides per node role
+type ThresholdConfig struct {
+	Thresholds `json:",inline"`
+	Roles      map[string]Thresholds `json:"roles,omitempty"`
+}
+
+// CheckConfig holds the optional rules used by --check
+type CheckConfig struct {
+	// HotNodes is the severity reported for nodes above their thresholds.
+	// Hot nodes are not reported when it is empty.
+	HotNodes string `json:"hotNodes,omitempty"`
+}
+
+// ThemeConfig selects the theme and overrides its symbols and colours
+type ThemeConfig struct {
+	// Name is the theme used when --theme is not given
+	Name string `json:"name,omitempty"`
+
+	// Symbols and Colors override the theme, keyed by symbol or colour name
+	Symbols map[string]string `json:"symbols,omitempty"`
+	Colors  map[string]string `json:"colors,omitempty"`
+}
+
+// File is the nodepp config file
+type File struct {
+	Thresholds ThresholdConfig `json:"thresholds,omitempty"`
+	Check      CheckConfig     `json:"check,omitempty"`
+	Theme      ThemeConfig     `json:"theme,omitempty"`
+
+	// Columns are custom columns added to the table when --columns is not
+	// given, in the same HEADER:JSONPATH form as the flag
+	Columns []string `json:"columns,omitempty"`
+
+	// Defaults holds default values for flags, keyed by flag name
+	Defaults map[string]interface{} `json:"defaults,omitempty"`
+}
+
+// DefaultPath returns the location of the config file when --config is not given
+func DefaultPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "nodepp", "config.yaml")
+}
+
+// Load reads the config file at path. A missing file is only an error if it
+// was asked for explicitly, otherwise an empty config is returned.
+func Load(path string, explicit bool) (*File, error) {
+	file := new(File)
+	if path == "" {
+		return file, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && !explicit {
+			return file, nil
+		}
+		return nil, err
+	}
+	if err := yaml.UnmarshalStrict(data, file); err != nil {
+		return nil, fmt.Errorf("parsing config file %s: %v", path, err)
+	}
+	return file, nil
+}
+
+// ApplyDefaults sets the flags named in the config file defaults, leaving any
+// flag given on the command line untouched. Defaults for flags that are only
+// defined in others, such as the root command's flags when running a
+// subcommand, are skipped.
+func (f *File) ApplyDefaults(flags *pflag.FlagSet, others ...*pflag.FlagSet) error {
+	for name, value := range f.Defaults {
+		flag := flags.Lookup(name)
+		if flag == nil {
+			if definedIn(name, others) {
+				continue
+			}
+			return fmt.Errorf("unknown flag %q in config file defaults", name)
+		}
+		if flag.Changed {
+			continue
+		}
+		if err := setDefault(flag, value); err != nil {
+			return fmt.Errorf("invalid default for %q in config file: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// setDefault sets a flag from a config file value. Lists set every value of
+// slice flags, or are joined with commas for other flags.
+func setDefault(flag *pflag.Flag, value interface{}) error {
+	list, ok := value.([]interface{})
+	if !ok {
+		return flag.Value.Set(fmt.Sprint(value))
+	}
+	values := make([]string, len(list))
+	for i, v := range list {
+		values[i] = fmt.Sprint(v)
+	}
+	if slice, ok := flag.Value.(pflag.SliceValue); ok {
+		return slice.Replace(values)
+	}
+	return flag.Value.Set(strings.Join(values, ","))
+}
+
+// definedIn reports whether any of the flag sets defines the named flag
+func definedIn(name string, flagSets []*pflag.FlagSet) bool {
+	for _, flags := range flagSets {
+		if flags.Lookup(name) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// For returns the thresholds for a node with the given roles. Role overrides
+// take precedence over the cluster-wide thresholds; when a node has several
+// roles with overrides, the lowest threshold wins.
+func (t ThresholdConfig) For(roles []string) Thresholds {
+	resolved := Thresholds{CPU: DefaultHotThreshold, Memory: DefaultHotThreshold, Pods: DefaultHotThreshold}
+	resolved.override(t.Thresholds)
+
+	var roleThresholds Thresholds
+	for _, role := range roles {
+		override, ok := t.Roles[role]
+		if !ok {
+			continue
+		}
+		roleThresholds.CPU = lowest(roleThresholds.CPU, override.CPU)
+		roleThresholds.Memory = lowest(roleThresholds.Memory, override.Memory)
+		roleThresholds.Pods = lowest(roleThresholds.Pods, override.Pods)
+	}
+	resolved.override(roleThresholds)
+	return resolved
+}
+
+// override replaces the thresholds that are set in o
+func (t *Thresholds) override(o Thresholds) {
+	if o.CPU > 0 {
+		t.CPU = o.CPU
+	}
+	if o.Memory > 0 {
+		t.Memory = o.Memory
+	}
+	if o.Pods > 0 {
+		t.Pods = o.Pods
+	}
+}
+
+// lowest returns the lower of two thresholds, ignoring unset values
+func lowest(a float64, b float64) float64 {
+	if a == 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestThresholdsFor(t *testing.T) {
+	thresholds := ThresholdConfig{
+		Thresholds: Thresholds{CPU: 80},
+		Roles: map[string]Thresholds{
+			"master": {CPU: 60, Memory: 70},
+			"infra":  {CPU: 50},
+		},
+	}
+
+	tests := []struct {
+		roles    []string
+		expected Thresholds
+	}{
+		{roles: nil, expected: Thresholds{CPU: 80, Memory: DefaultHotThreshold, Pods: DefaultHotThreshold}},
+		{roles: []string{"worker"}, expected: Thresholds{CPU: 80, Memory: DefaultHotThreshold, Pods: DefaultHotThreshold}},
+		{roles: []string{"master"}, expected: Thresholds{CPU: 60, Memory: 70, Pods: DefaultHotThreshold}},
+		{roles: []string{"master", "infra"}, expected: Thresholds{CPU: 50, Memory: 70, Pods: DefaultHotThreshold}},
+	}
+	for _, test := range tests {
+		if got := thresholds.For(test.roles); got != test.expected {
+			t.Errorf("roles %v: expected %+v, got %+v", test.roles, test.expected, got)
+		}
+	}
+}
+
+func TestLoadAndApplyDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `
+thresholds:
+  cpu: 75
+  roles:
+    master:
+      memory: 60
+columns:
+- COST:.metadata.labels.example\.com/cost-centre
+defaults:
+  show-operators: false
+  watch-interval: 10s
+  sort-by: [status:desc, cpu:desc]
+  check: true
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := Load(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Thresholds.CPU != 75 || file.Thresholds.Roles["master"].Memory != 60 {
+		t.Errorf("unexpected thresholds: %+v", file.Thresholds)
+	}
+	if len(file.Columns) != 1 || file.Columns[0] != `COST:.metadata.labels.example\.com/cost-centre` {
+		t.Errorf("unexpected columns: %v", file.Columns)
+	}
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	showOperators := flags.Bool(ShowOperators, true, "")
+	watchInterval := flags.Duration(WatchInterval, 0, "")
+	sortBy := flags.StringSlice(SortBy, nil, "")
+	flags.Bool(Check, false, "")
+	if err := flags.Parse([]string{"--" + WatchInterval + "=1m"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.ApplyDefaults(flags); err != nil {
+		t.Fatal(err)
+	}
+	if *showOperators {
+		t.Errorf("expected the config file default to be applied")
+	}
+	if watchInterval.String() != "1m0s" {
+		t.Errorf("expected the command line to take precedence, got %v", watchInterval)
+	}
+	if len(*sortBy) != 2 || (*sortBy)[0] != "status:desc" || (*sortBy)[1] != "cpu:desc" {
+		t.Errorf("expected a list default to set every value, got %v", *sortBy)
+	}
+
+	// a subcommand without the root command's sort and check flags
+	subcommand := pflag.NewFlagSet("subcommand", pflag.ContinueOnError)
+	subcommand.Bool(ShowOperators, true, "")
+	subcommand.Duration(WatchInterval, 0, "")
+	if err := file.ApplyDefaults(subcommand, flags); err != nil {
+		t.Errorf("defaults for root command flags should be skipped: %v", err)
+	}
+	if err := file.ApplyDefaults(subcommand); err == nil {
+		t.Errorf("expected an error for flags that no command defines")
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.yaml")
+	if _, err := Load(path, false); err != nil {
+		t.Errorf("a missing default config file should be ignored: %v", err)
+	}
+	if _, err := Load(path, true); err == nil {
+		t.Errorf("a missing explicit config file should be an error")
+	}
+}
@@ -5,8 +5,25 @@ const (
 	Annotation_Machine              = "machine.openshift.io/machine"
 	Annotation_MachineCurrentConfig = "machineconfiguration.openshift.io/currentConfig"
 	Annotation_MachineDesiredConfig = "machineconfiguration.openshift.io/desiredConfig"
+	Annotation_MachineConfigState   = "machineconfiguration.openshift.io/state"
+	Annotation_MachineConfigReason  = "machineconfiguration.openshift.io/reason"
+	Annotation_InstanceState        = "machine.openshift.io/instance-state"
+
+	MachineConfigStateDone     = "Done"
+	MachineConfigStateWorking  = "Working"
+	MachineConfigStateDegraded = "Degraded"
+	WorkerPoolName             = "worker"
 
 	Label_MasterNodeRole = "node-role.kubernetes.io/master"
 	Label_WorkerNodeRole = "node-role.kubernetes.io/worker"
 	Label_InfraNodeRole  = "node-role.kubernetes.io/infra"
+	Label_ControlPlane   = "node-role.kubernetes.io/control-plane"
+	Label_MachineRole    = "machine.openshift.io/cluster-api-machine-role"
+	Label_Zone           = "topology.kubernetes.io/zone"
+	Label_Region         = "topology.kubernetes.io/region"
+	Label_InstanceType   = "node.kubernetes.io/instance-type"
+	Label_MachineZone    = "machine.openshift.io/zone"
+	Label_MachineRegion  = "machine.openshift.io/region"
+	Label_MachineType    = "machine.openshift.io/instance-type"
+	Label_MachineSet     = "machine.openshift.io/cluster-api-machineset"
 )
@@ -18,4 +18,5 @@ const (
 	EMOJI_GEAR      = '\U00002699'
 	EMOJI_SOON      = '\U0001F51C'
 	EMOJI_WARN      = '\U000026A0'
+	EMOJI_REFRESH   = '\U0001F504'
 )
@@ -0,0 +1,198 @@
+package health
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	v1 "github.com/openshift/api/config/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"nodepp/internal/config"
+	"nodepp/internal/consts"
+	"nodepp/internal/structs"
+	"nodepp/internal/util"
+)
+
+// Severity ranks how badly a finding affects the cluster
+type Severity int
+
+const (
+	Healthy Severity = iota
+	Degraded
+	Critical
+)
+
+// ParseSeverity returns the severity with the given name
+func ParseSeverity(name string) (Severity, error) {
+	for _, s := range []Severity{Healthy, Degraded, Critical} {
+		if s.String() == name {
+			return s, nil
+		}
+	}
+	return Healthy, fmt.Errorf("unknown severity %q, expected one of healthy|degraded|critical", name)
+}
+
+func (s Severity) String() string {
+	switch s {
+	case Degraded:
+		return "degraded"
+	case Critical:
+		return "critical"
+	}
+	return "healthy"
+}
+
+// Finding is a single reason the cluster is not healthy
+type Finding struct {
+	Severity Severity
+	Reason   string
+}
+
+// Options holds the configurable health rules
+type Options struct {
+	// Thresholds are the utilization percentages above which a node is hot
+	Thresholds config.ThresholdConfig
+
+	// HotNodes is the severity reported for hot nodes. Hot nodes are not
+	// reported when it is Healthy.
+	HotNodes Severity
+
+	// Now is the time that machine provisioning is measured from. The
+	// current time is used when it is zero.
+	Now time.Time
+}
+
+// Report holds the findings of a health check
+type Report struct {
+	Findings []Finding
+}
+
+// Status returns the most severe finding, or Healthy if there are none
+func (r *Report) Status() Severity {
+	status := Healthy
+	for _, f := range r.Findings {
+		if f.Severity > status {
+			status = f.Severity
+		}
+	}
+	return status
+}
+
+// addHot reports the resources of a node that are above their thresholds
+func (r *Report) addHot(cd *structs.ClusterData, n *structs.NodeData, opts Options) {
+	thresholds := opts.Thresholds.For(n.Roles)
+	if !cd.Unavailable(structs.SourceNodeMetrics) {
+		if n.Cpu != nil && n.Cpu.UtilizationPercent() > thresholds.CPU {
+			r.add(opts.HotNodes, "node %s cpu usage is %d%%", n.NodeName, int64(n.Cpu.UtilizationPercent()))
+		}
+		if n.Memory != nil && n.Memory.UtilizationPercent() > thresholds.Memory {
+			r.add(opts.HotNodes, "node %s memory usage is %d%%", n.NodeName, int64(n.Memory.UtilizationPercent()))
+		}
+	}
+	if !cd.Unavailable(structs.SourcePods) && n.Pods != nil && n.Pods.UtilizationPercent() > thresholds.Pods {
+		r.add(opts.HotNodes, "node %s is running %d of %d pods", n.NodeName, n.Pods.Utilization.Value(), n.Pods.Allocatable.Value())
+	}
+}
+
+// machineErrorSuffix explains why a machine failed, if its provider said
+func machineErrorSuffix(n *structs.NodeData) string {
+	if n.MachineError == nil {
+		return ""
+	}
+	return fmt.Sprintf(": %s: %s", n.MachineError.Reason, n.MachineError.Message)
+}
+
+func (r *Report) add(severity Severity, format string, a ...interface{}) {
+	r.Findings = append(r.Findings, Finding{Severity: severity, Reason: fmt.Sprintf(format, a...)})
+}
+
+// Evaluate checks the collected cluster data against the health rules.
+// Nodes that are not ready, failed machines, unavailable operators and a
+// failing cluster update are critical; anything that needs attention but is
+// still serving, including data sources that could not be checked, is
+// degraded. Nodes above their utilization thresholds are reported with the
+// configured severity.
+func Evaluate(cd *structs.ClusterData, opts Options) *Report {
+	report := new(Report)
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	for _, n := range cd.Nodes {
+		if n.NodeName == "" {
+			switch n.MachinePhase {
+			case "Failed":
+				report.add(Critical, "machine %s has failed%s", n.MachineName, machineErrorSuffix(n))
+			case "Provisioning", "Provisioned":
+				if n.MachineStuck(now) {
+					report.add(Degraded, "machine %s has been %s for %s without a node", n.MachineName,
+						n.MachinePhase, duration.HumanDuration(now.Sub(n.Created.Time)))
+				}
+				// otherwise the machine is expected to have no node yet
+			case "Deleting":
+				// machines being deleted are expected to lose their node
+			default:
+				report.add(Degraded, "machine %s has no node", n.MachineName)
+			}
+			continue
+		}
+		if !n.Ready {
+			report.add(Critical, "node %s is not ready", n.NodeName)
+		}
+		if n.MachinePhase == "Failed" {
+			report.add(Critical, "machine %s of node %s has failed%s", n.MachineName, n.NodeName, machineErrorSuffix(n))
+		}
+		if n.Cordoned && !n.Updating {
+			report.add(Degraded, "node %s is cordoned", n.NodeName)
+		}
+		if n.MemoryPressure {
+			report.add(Degraded, "node %s is under memory pressure", n.NodeName)
+		}
+		if n.DiskPressure {
+			report.add(Degraded, "node %s is under disk pressure", n.NodeName)
+		}
+		if n.MCDState == consts.MachineConfigStateDegraded {
+			report.add(Degraded, "node %s machine config is degraded: %s", n.NodeName, n.MCDReason)
+		}
+		if opts.HotNodes != Healthy {
+			report.addHot(cd, n, opts)
+		}
+	}
+
+	for _, pool := range cd.Pools {
+		if pool.Degraded {
+			report.add(Degraded, "pool %s is degraded: %s", pool.Name, pool.DegradedMessage)
+		}
+	}
+
+	for _, co := range cd.UnhealthyOperators() {
+		if co.Down {
+			report.add(Critical, "operator %s is unavailable", co.Name)
+		} else {
+			report.add(Degraded, "operator %s is degraded", co.Name)
+		}
+	}
+
+	if cd.Version != nil {
+		if failing := util.GetCondition(cd.Version, util.ClusterVersionFailing); failing != nil && failing.Status == v1.ConditionTrue {
+			report.add(Critical, "cluster update is failing: %s", failing.Message)
+		}
+	}
+
+	for _, warning := range cd.Warnings {
+		if warning.NotServed {
+			// the cluster has no such API to check, as on HyperShift hosted
+			// clusters without the Machine API
+			continue
+		}
+		report.add(Degraded, "%s could not be checked: %s", warning.Source, warning.Message)
+	}
+
+	// most severe findings first, keeping the order within a severity
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return report.Findings[i].Severity > report.Findings[j].Severity
+	})
+	return report
+}
@@ -0,0 +1,137 @@
+package health
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/openshift/api/config/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"nodepp/internal/config"
+	"nodepp/internal/structs"
+)
+
+func pods(allocatable string, utilization string) *structs.ResourceMetric {
+	return &structs.ResourceMetric{Allocatable: resource.MustParse(allocatable), Utilization: resource.MustParse(utilization)}
+}
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		cd       *structs.ClusterData
+		opts     Options
+		status   Severity
+		findings int
+	}{
+		{
+			name:   "healthy",
+			cd:     &structs.ClusterData{Nodes: []*structs.NodeData{{NodeName: "worker-0", MachineName: "worker-0-machine", MachinePhase: "Running", Ready: true}}},
+			status: Healthy,
+		},
+		{
+			name: "provisioning machine",
+			cd: &structs.ClusterData{Nodes: []*structs.NodeData{
+				{NodeName: "worker-0", Ready: true},
+				{MachineName: "worker-1-machine", MachinePhase: "Provisioning"},
+			}},
+			status: Healthy,
+		},
+		{
+			name: "machine stuck provisioning",
+			cd: &structs.ClusterData{Nodes: []*structs.NodeData{
+				{NodeName: "worker-0", Ready: true},
+				{MachineName: "worker-1-machine", MachinePhase: "Provisioned", Created: metav1.NewTime(now.Add(-2 * time.Hour))},
+			}},
+			opts:     Options{Now: now},
+			status:   Degraded,
+			findings: 1,
+		},
+		{
+			name: "cordoned node and degraded pool",
+			cd: &structs.ClusterData{
+				Nodes: []*structs.NodeData{{NodeName: "worker-0", Ready: true, Cordoned: true}},
+				Pools: []*structs.PoolData{{Name: "worker", Degraded: true}},
+			},
+			status:   Degraded,
+			findings: 2,
+		},
+		{
+			name: "not ready node and unavailable source",
+			cd: &structs.ClusterData{
+				Nodes:    []*structs.NodeData{{NodeName: "worker-0"}},
+				Warnings: []structs.Warning{{Source: structs.SourceMachines, Message: "forbidden"}},
+			},
+			status:   Critical,
+			findings: 2,
+		},
+		{
+			name: "machine api not served",
+			cd: &structs.ClusterData{
+				Nodes: []*structs.NodeData{{NodeName: "worker-0", Ready: true}},
+				Warnings: []structs.Warning{
+					structs.NewWarning(structs.SourceMachines, apierrors.NewNotFound(schema.GroupResource{Group: "machine.openshift.io", Resource: "machines"}, "")),
+					structs.NewWarning(structs.SourceMachineSets, apierrors.NewNotFound(schema.GroupResource{Group: "machine.openshift.io", Resource: "machinesets"}, "")),
+				},
+			},
+			status: Healthy,
+		},
+		{
+			name: "metrics server down",
+			cd: &structs.ClusterData{
+				Nodes:    []*structs.NodeData{{NodeName: "worker-0", Ready: true}},
+				Warnings: []structs.Warning{structs.NewWarning(structs.SourceNodeMetrics, apierrors.NewServiceUnavailable("the server is currently unable to handle the request"))},
+			},
+			status:   Degraded,
+			findings: 1,
+		},
+		{
+			name: "down operator",
+			cd: &structs.ClusterData{ClusterOperators: &v1.ClusterOperatorList{Items: []v1.ClusterOperator{{
+				ObjectMeta: metav1.ObjectMeta{Name: "dns"},
+				Status: v1.ClusterOperatorStatus{Conditions: []v1.ClusterOperatorStatusCondition{
+					{Type: v1.OperatorAvailable, Status: v1.ConditionFalse},
+				}},
+			}}}},
+			status:   Critical,
+			findings: 1,
+		},
+		{
+			name: "hot node ignored by default",
+			cd: &structs.ClusterData{Nodes: []*structs.NodeData{
+				{NodeName: "worker-0", Ready: true, Roles: []string{"worker"}, Pods: pods("110", "105")},
+			}},
+			status: Healthy,
+		},
+		{
+			name: "hot node above role threshold",
+			cd: &structs.ClusterData{Nodes: []*structs.NodeData{
+				{NodeName: "worker-0", Ready: true, Roles: []string{"worker"}, Pods: pods("110", "90")},
+			}},
+			opts: Options{
+				Thresholds: config.ThresholdConfig{Roles: map[string]config.Thresholds{"worker": {Pods: 80}}},
+				HotNodes:   Degraded,
+			},
+			status:   Degraded,
+			findings: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			report := Evaluate(test.cd, test.opts)
+			if report.Status() != test.status {
+				t.Errorf("expected %s, got %s: %v", test.status, report.Status(), report.Findings)
+			}
+			if len(report.Findings) != test.findings {
+				t.Errorf("expected %d findings, got %v", test.findings, report.Findings)
+			}
+			if len(report.Findings) > 0 && report.Findings[0].Severity != test.status {
+				t.Errorf("expected the most severe finding first, got %v", report.Findings)
+			}
+		})
+	}
+}
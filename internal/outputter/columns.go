@@ -0,0 +1,160 @@
+package outputter
+
+import (
+	"nodepp/internal/consts"
+	"nodepp/internal/structs"
+)
+
+// column is a column of the node table
+type column struct {
+	header string
+
+	// shown reports whether the column is displayed with the renderer's
+	// settings. Columns without it are always displayed.
+	shown func(o *TableRenderer) bool
+
+	// value returns the cell for a row
+	value func(o *TableRenderer, cd *structs.ClusterData, n *structs.NodeData) string
+}
+
+func showTopology(o *TableRenderer) bool { return o.ShowTopology }
+func showWide(o *TableRenderer) bool     { return o.Wide }
+func showPoolData(o *TableRenderer) bool { return o.ShowPools }
+func showUsage(o *TableRenderer) bool    { return o.ShowUsage }
+
+// columns are the columns of the node table, in display order
+var columns = []column{
+	{header: " ", value: func(o *TableRenderer, cd *structs.ClusterData, n *structs.NodeData) string {
+		if !n.Ready {
+			return o.Theme.Symbols.NotReady
+		}
+		return ""
+	}},
+	{header: "NODE", value: func(o *TableRenderer, cd *structs.ClusterData, n *structs.NodeData) string {
+		if n.NodeName == "" {
+			return o.Theme.Symbols.Missing
+		}
+		return n.NodeName
+	}},
+	{header: "MACHINE", value: func(o *TableRenderer, cd *structs.ClusterData, n *structs.NodeData) string {
+		// the node's machine annotation names its machine even when
+		// machines could not be retrieved
+		if n.MachineName == "" && cd.Unavailable(structs.SourceMachines) {
+			return unavailable
+		}
+		return n.MachineName
+	}},
+	{header: "ROLE", value: func(o *TableRenderer, cd *structs.ClusterData, n *structs.NodeData) string {
+		if len(n.Roles) == 0 {
+			return ""
+		}
+		return makeRoleValue(n.Roles, o.Theme.Symbols)
+	}},
+	{header: "ZONE", shown: showTopology, value: nodeValue(func(n *structs.NodeData) string { return n.Zone })},
+	{header: "TYPE", shown: showTopology, value: nodeValue(func(n *structs.NodeData) string { return n.InstanceType })},
+	{header: "AGE", value: nodeValue(func(n *structs.NodeData) string { return n.Age })},
+	{header: "STATUS", value: makeStatusValue},
+	{header: "INTERNAL-IP", shown: showWide, value: nodeValue(func(n *structs.NodeData) string { return n.InternalIP })},
+	{header: "EXTERNAL-IP", shown: showWide, value: nodeValue(func(n *structs.NodeData) string { return n.ExternalIP })},
+	{header: "KUBELET", shown: showWide, value: nodeValue(func(n *structs.NodeData) string { return n.KubeletVersion })},
+	{header: "OS-IMAGE", shown: showWide, value: nodeValue(func(n *structs.NodeData) string { return n.OSImage })},
+	{header: "KERNEL-VERSION", shown: showWide, value: nodeValue(func(n *structs.NodeData) string { return n.KernelVersion })},
+	{header: "CONTAINER-RUNTIME", shown: showWide, value: nodeValue(func(n *structs.NodeData) string { return n.RuntimeVersion })},
+	{header: "INSTANCE", shown: showWide, value: machineValue(func(n *structs.NodeData) string { return n.InstanceState })},
+	{header: "PROVIDER-ID", shown: showWide, value: machineValue(func(n *structs.NodeData) string { return n.ProviderID })},
+	{header: "ERROR", shown: showWide, value: machineValue(func(n *structs.NodeData) string {
+		if n.MachineError == nil {
+			return ""
+		}
+		return n.MachineError.Reason
+	})},
+	{header: "POOL", shown: showPoolData, value: func(o *TableRenderer, cd *structs.ClusterData, n *structs.NodeData) string {
+		if cd.Unavailable(structs.SourceMachineConfigPools) {
+			return unavailable
+		}
+		return n.Pool
+	}},
+	{header: "MCD", shown: showPoolData, value: func(o *TableRenderer, cd *structs.ClusterData, n *structs.NodeData) string {
+		if n.MCDState == consts.MachineConfigStateDegraded {
+			return o.Theme.Symbols.mark(n.MCDState, o.Theme.Symbols.Warning)
+		}
+		return n.MCDState
+	}},
+	{header: "CPU", shown: showUsage, value: func(o *TableRenderer, cd *structs.ClusterData, n *structs.NodeData) string {
+		return makeCpuValue(cd, n, o.Thresholds.For(n.Roles).CPU, o.Theme.Symbols)
+	}},
+	{header: "CPU REQ/LIM", shown: showUsage, value: func(o *TableRenderer, cd *structs.ClusterData, n *structs.NodeData) string {
+		return makeCommitmentValue(cd, n, n.Cpu, o.Thresholds.For(n.Roles).CPU, o.Theme.Symbols)
+	}},
+	{header: "MEMORY", shown: showUsage, value: func(o *TableRenderer, cd *structs.ClusterData, n *structs.NodeData) string {
+		return makeMemoryValue(cd, n, o.Thresholds.For(n.Roles).Memory, o.Theme.Symbols)
+	}},
+	{header: "MEM REQ/LIM", shown: showUsage, value: func(o *TableRenderer, cd *structs.ClusterData, n *structs.NodeData) string {
+		return makeCommitmentValue(cd, n, n.Memory, o.Thresholds.For(n.Roles).Memory, o.Theme.Symbols)
+	}},
+	{header: "PODS", shown: showUsage, value: func(o *TableRenderer, cd *structs.ClusterData, n *structs.NodeData) string {
+		return makePodsValue(cd, n, o.Thresholds.For(n.Roles).Pods, o.Theme.Symbols)
+	}},
+}
+
+// nodeValue returns a cell that shows a field as it is
+func nodeValue(field func(n *structs.NodeData) string) func(*TableRenderer, *structs.ClusterData, *structs.NodeData) string {
+	return func(o *TableRenderer, cd *structs.ClusterData, n *structs.NodeData) string {
+		return field(n)
+	}
+}
+
+// machineValue returns a cell that shows a field read from the machine, or
+// marks it unavailable if machines could not be retrieved
+func machineValue(field func(n *structs.NodeData) string) func(*TableRenderer, *structs.ClusterData, *structs.NodeData) string {
+	return func(o *TableRenderer, cd *structs.ClusterData, n *structs.NodeData) string {
+		if cd.Unavailable(structs.SourceMachines) {
+			return unavailable
+		}
+		return field(n)
+	}
+}
+
+// makeStatusValue marks the node and machine states that need attention
+func makeStatusValue(o *TableRenderer, cd *structs.ClusterData, n *structs.NodeData) string {
+	s := o.Theme.Symbols
+	var status []string
+	if n.Updating {
+		status = append(status, s.Updating)
+	}
+	if n.Cordoned {
+		status = append(status, s.Cordoned)
+	}
+	switch n.MachinePhase {
+	case "Failed":
+		status = append(status, s.Failed)
+	case "Deleting":
+		status = append(status, s.Deleting)
+	case "Provisioned":
+		status = append(status, s.Provisioning)
+	case "Provisioning":
+		status = append(status, s.Provisioning)
+	}
+	if n.MemoryPressure {
+		status = append(status, s.MemoryPressure)
+	}
+	if n.DiskPressure {
+		status = append(status, s.DiskPressure)
+	}
+	return s.mark("", status...)
+}
+
+// shownColumns returns the columns displayed with the renderer's settings,
+// followed by any custom columns
+func (o *TableRenderer) shownColumns() []column {
+	shown := make([]column, 0, len(columns))
+	for _, c := range columns {
+		if c.shown == nil || c.shown(o) {
+			shown = append(shown, c)
+		}
+	}
+	for _, c := range o.CustomColumns {
+		shown = append(shown, c.column())
+	}
+	return shown
+}
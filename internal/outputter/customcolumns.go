@@ -0,0 +1,140 @@
+package outputter
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/jsonpath"
+
+	"nodepp/internal/structs"
+)
+
+// Objects that custom columns can be evaluated against
+const (
+	SourceNode    = "node"
+	SourceMachine = "machine"
+)
+
+// CustomColumn is a column defined by a JSONPath expression, evaluated
+// against the Node or Machine of each row as kubectl's custom-columns are
+type CustomColumn struct {
+	Header string
+	Source string
+	Path   string
+
+	parser *jsonpath.JSONPath
+}
+
+// ParseCustomColumns parses column specs of the form HEADER:JSONPATH, which
+// are evaluated against the Node, or HEADER:machine:JSONPATH, which are
+// evaluated against the Machine. JSONPATH may be a template such as
+// {.metadata.name} or a relaxed path such as .metadata.name.
+func ParseCustomColumns(specs []string) ([]CustomColumn, error) {
+	columns := make([]CustomColumn, 0, len(specs))
+	for _, spec := range specs {
+		header, path, ok := strings.Cut(spec, ":")
+		if !ok || header == "" || path == "" {
+			return nil, fmt.Errorf("invalid custom column %q, expected HEADER:JSONPATH", spec)
+		}
+		source := SourceNode
+		for _, s := range []string{SourceNode, SourceMachine} {
+			if strings.HasPrefix(path, s+":") {
+				source, path = s, strings.TrimPrefix(path, s+":")
+			}
+		}
+
+		parser := jsonpath.New(header).AllowMissingKeys(true)
+		if err := parser.Parse(relaxedJSONPath(path)); err != nil {
+			return nil, fmt.Errorf("invalid JSONPath in custom column %q: %v", spec, err)
+		}
+		columns = append(columns, CustomColumn{Header: header, Source: source, Path: path, parser: parser})
+	}
+	return columns, nil
+}
+
+// relaxedJSONPath wraps a bare path such as .metadata.name in braces, as
+// kubectl does
+func relaxedJSONPath(path string) string {
+	if strings.HasPrefix(path, "{") {
+		return path
+	}
+	if !strings.HasPrefix(path, ".") {
+		path = "." + path
+	}
+	return "{" + path + "}"
+}
+
+// objectCache holds the unstructured form of the Node and Machine of the
+// last row seen, so that the custom columns of a row share one conversion
+type objectCache struct {
+	row     *structs.NodeData
+	objects map[string]map[string]interface{}
+}
+
+// get returns the row's object for the source as unstructured data, or nil
+// if the row does not have one
+func (oc *objectCache) get(n *structs.NodeData, source string) (map[string]interface{}, error) {
+	if oc.row != n {
+		oc.row, oc.objects = n, make(map[string]map[string]interface{})
+	}
+	if data, ok := oc.objects[source]; ok {
+		return data, nil
+	}
+	var obj interface{}
+	switch {
+	case source == SourceMachine && n.Machine != nil:
+		obj = n.Machine
+	case source == SourceNode && n.Node != nil:
+		obj = n.Node
+	default:
+		return nil, nil
+	}
+	data, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	oc.objects[source] = data
+	return data, nil
+}
+
+// Value evaluates the column against the row's source object. Rows without
+// the object, such as machines without a node, have no value.
+func (c CustomColumn) Value(n *structs.NodeData) (string, error) {
+	return c.value(n, new(objectCache))
+}
+
+// value evaluates the column against the row's source object, converting it
+// through the cache
+func (c CustomColumn) value(n *structs.NodeData, cache *objectCache) (string, error) {
+	data, err := cache.get(n, c.Source)
+	if err != nil || data == nil {
+		return "", err
+	}
+	results, err := c.parser.FindResults(data)
+	if err != nil {
+		return "", err
+	}
+	values := make([]string, 0)
+	for _, result := range results {
+		for _, value := range result {
+			values = append(values, fmt.Sprint(value.Interface()))
+		}
+	}
+	return strings.Join(values, ","), nil
+}
+
+// column returns the table column for the custom column. Expressions that
+// cannot be evaluated for a row show the error in its cell.
+func (c CustomColumn) column() column {
+	return column{header: c.Header, value: func(o *TableRenderer, cd *structs.ClusterData, n *structs.NodeData) string {
+		if c.Source == SourceMachine && cd.Unavailable(structs.SourceMachines) {
+			return unavailable
+		}
+		value, err := c.value(n, &o.objects)
+		if err != nil {
+			return fmt.Sprintf("<error: %v>", err)
+		}
+		return value
+	}}
+}
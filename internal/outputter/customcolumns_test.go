@@ -0,0 +1,85 @@
+package outputter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/openshift/api/machine/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"nodepp/internal/structs"
+)
+
+func TestCustomColumns(t *testing.T) {
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name: "worker-0",
+		Labels: map[string]string{
+			"example.com/cost-centre":       "cc-1234",
+			"nvidia.com/gpu.product":        "NVIDIA-A10G",
+			"node-role.kubernetes.io/infra": "",
+		},
+	}}
+	machine := &v1beta1.Machine{ObjectMeta: metav1.ObjectMeta{
+		Name:        "worker-0-machine",
+		Annotations: map[string]string{"machine.openshift.io/instance-type": "g5.xlarge"},
+	}}
+	withMachine := &structs.NodeData{Node: node, Machine: machine}
+	machineOnly := &structs.NodeData{Machine: machine}
+
+	columns, err := ParseCustomColumns([]string{
+		`COST:.metadata.labels.example\.com/cost-centre`,
+		`GPU:{.metadata.labels.nvidia\.com/gpu\.product}`,
+		`MACHINE TYPE:machine:.metadata.annotations.machine\.openshift\.io/instance-type`,
+		`TAINTS:node:.spec.taints[*].key`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		row      *structs.NodeData
+		expected []string
+	}{
+		{withMachine, []string{"cc-1234", "NVIDIA-A10G", "g5.xlarge", ""}},
+		{machineOnly, []string{"", "", "g5.xlarge", ""}},
+	}
+	for _, test := range tests {
+		for i, column := range columns {
+			value, err := column.Value(test.row)
+			if err != nil {
+				t.Errorf("%s: %v", column.Header, err)
+				continue
+			}
+			if value != test.expected[i] {
+				t.Errorf("%s: expected %q, got %q", column.Header, test.expected[i], value)
+			}
+		}
+	}
+
+	for _, spec := range []string{"COST", ":.metadata.name", "COST:{.metadata.labels"} {
+		if _, err := ParseCustomColumns([]string{spec}); err == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+}
+
+func TestCustomColumnsConvertEachRowOnce(t *testing.T) {
+	row := &structs.NodeData{Node: &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-0"}}}
+	cache := new(objectCache)
+	first, err := cache.get(row, SourceNode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := cache.get(row, SourceNode)
+	if reflect.ValueOf(first).Pointer() != reflect.ValueOf(second).Pointer() {
+		t.Errorf("expected the row's node to be converted once")
+	}
+
+	other := &structs.NodeData{Node: &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}}}
+	data, _ := cache.get(other, SourceNode)
+	if name, _, _ := unstructured.NestedString(data, "metadata", "name"); name != "worker-1" {
+		t.Errorf("expected the next row to be converted, got %q", name)
+	}
+}
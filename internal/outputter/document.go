@@ -0,0 +1,90 @@
+package outputter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	v1 "github.com/openshift/api/config/v1"
+	"sigs.k8s.io/yaml"
+
+	"nodepp/internal/structs"
+)
+
+const (
+	FormatTable = "table"
+	FormatWide  = "wide"
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+
+	// DocumentAPIVersion is bumped whenever the document schema changes
+	// incompatibly. Fields may be added without changing it.
+	DocumentAPIVersion = "nodepp/v1"
+	DocumentKind       = "ClusterData"
+)
+
+// Document is the stable, machine-readable form of the merged cluster view.
+// Optional fields, such as the zone and MachineSet of each row, are added to
+// the current API version and omitted when unset.
+type Document struct {
+	APIVersion         string                    `json:"apiVersion"`
+	Kind               string                    `json:"kind"`
+	Nodes              []*structs.NodeData       `json:"nodes"`
+	ClusterVersion     *v1.ClusterVersion        `json:"clusterVersion,omitempty"`
+	UnhealthyOperators []*structs.OperatorStatus `json:"unhealthyOperators,omitempty"`
+	MachineConfigPools []*structs.PoolData       `json:"machineConfigPools,omitempty"`
+	MachineSets        []*structs.MachineSetData `json:"machineSets,omitempty"`
+	Warnings           []structs.Warning         `json:"warnings,omitempty"`
+}
+
+// NewDocument builds a versioned document from the collected cluster data
+func NewDocument(cd *structs.ClusterData) *Document {
+	doc := &Document{
+		APIVersion:         DocumentAPIVersion,
+		Kind:               DocumentKind,
+		Nodes:              cd.Nodes,
+		ClusterVersion:     cd.Version,
+		MachineConfigPools: cd.Pools,
+		MachineSets:        cd.MachineSets,
+		Warnings:           cd.Warnings,
+	}
+	if cd.ClusterOperators != nil {
+		doc.UnhealthyOperators = cd.UnhealthyOperators()
+	}
+	return doc
+}
+
+// DocumentRenderer serialises the cluster data as a versioned JSON or YAML document
+type DocumentRenderer struct {
+	Format string
+	SortBy []structs.SortKey
+
+	// Compact writes JSON documents on a single line instead of indented
+	Compact bool
+}
+
+func (d *DocumentRenderer) Render(cd *structs.ClusterData, w io.Writer) error {
+	cd.Sort(sortKeys(d.SortBy))
+	if d.Compact && d.Format == FormatJSON {
+		return json.NewEncoder(w).Encode(NewDocument(cd))
+	}
+	return encodeDocument(w, d.Format, NewDocument(cd))
+}
+
+// encodeDocument writes a document to the stream as indented JSON or YAML
+func encodeDocument(w io.Writer, format string, doc interface{}) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "    ")
+		return enc.Encode(doc)
+	case FormatYAML:
+		out, err := yaml.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(out)
+		return err
+	}
+	return fmt.Errorf("unsupported output format %q", format)
+}
@@ -0,0 +1,97 @@
+package outputter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/openshift/api/machine/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"nodepp/internal/structs"
+)
+
+func TestDocumentRoundTrip(t *testing.T) {
+	created := metav1.NewTime(time.Date(2023, 4, 1, 12, 30, 0, 0, time.UTC))
+	cd := &structs.ClusterData{
+		Nodes: []*structs.NodeData{
+			{NodeName: "worker-0", MachineName: "worker-0-machine", MachinePhase: "Running", Age: "42d", Created: created,
+				Roles: []string{"worker"}, Ready: true, Cpu: committed(metric("4", "3900m"), "3", "6")},
+			{MachineName: "worker-1-machine", MachinePhase: "Provisioning", Created: created, Roles: []string{}},
+		},
+		Pools:       []*structs.PoolData{{Name: "worker", MachineCount: 2, UpdatedMachineCount: 1, Updating: true}},
+		MachineSets: []*structs.MachineSetData{{Name: "worker-a", Desired: 2, Current: 2, Ready: 1}},
+	}
+	cd.AddWarning(structs.SourceNodeMetrics, errors.New("metrics unavailable"))
+
+	for _, format := range []string{FormatJSON, FormatYAML} {
+		var out bytes.Buffer
+		if err := (&DocumentRenderer{Format: format}).Render(cd, &out); err != nil {
+			t.Fatal(err)
+		}
+
+		doc := new(Document)
+		if err := yaml.UnmarshalStrict(out.Bytes(), doc); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if doc.APIVersion != DocumentAPIVersion || doc.Kind != DocumentKind {
+			t.Errorf("%s: unexpected document type %s %s", format, doc.APIVersion, doc.Kind)
+		}
+		if len(doc.Nodes) != 2 || !doc.Nodes[0].Created.Equal(&created) || doc.Nodes[0].Age != "" {
+			t.Errorf("%s: expected rows with their creation timestamp and no age, got %+v", format, doc.Nodes)
+		}
+
+		var again bytes.Buffer
+		if err := encodeDocument(&again, format, doc); err != nil {
+			t.Fatal(err)
+		}
+		if again.String() != out.String() {
+			t.Errorf("%s: document changed after a round trip:\n%s\n%s", format, out.String(), again.String())
+		}
+	}
+
+	var out bytes.Buffer
+	if err := (&DocumentRenderer{Format: FormatJSON}).Render(cd, &out); err != nil {
+		t.Fatal(err)
+	}
+	var raw struct {
+		Nodes []map[string]interface{} `json:"nodes"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &raw); err != nil {
+		t.Fatal(err)
+	}
+	if raw.Nodes[0]["created"] != "2023-04-01T12:30:00Z" {
+		t.Errorf("expected an RFC 3339 created timestamp, got %v", raw.Nodes[0]["created"])
+	}
+	if _, ok := raw.Nodes[0]["age"]; ok {
+		t.Errorf("expected no humanised age in the document")
+	}
+}
+
+func TestDocumentMarksMachinesWithoutNodesMissing(t *testing.T) {
+	machine, err := structs.NewFromMachine(&v1beta1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker-1-machine"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cd := new(structs.ClusterData)
+	cd.AddMachine(machine)
+
+	var out bytes.Buffer
+	if err := (&DocumentRenderer{Format: FormatYAML}).Render(cd, &out); err != nil {
+		t.Fatal(err)
+	}
+	var raw struct {
+		Nodes []map[string]interface{} `json:"nodes"`
+	}
+	if err := yaml.Unmarshal(out.Bytes(), &raw); err != nil {
+		t.Fatal(err)
+	}
+	if len(raw.Nodes) != 1 || raw.Nodes[0]["missing"] != true {
+		t.Errorf("expected the machine-only row to be missing its node, got %v", raw.Nodes)
+	}
+}
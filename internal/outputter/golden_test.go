@@ -0,0 +1,475 @@
+package outputter
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v1 "github.com/openshift/api/config/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"nodepp/internal/config"
+	"nodepp/internal/structs"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// goldenNow is the fixed time that relative durations are rendered against
+var goldenNow = time.Date(2023, 6, 2, 12, 0, 0, 0, time.UTC)
+
+func at(hoursBeforeNow float64) *metav1.Time {
+	t := metav1.NewTime(goldenNow.Add(-time.Duration(hoursBeforeNow * float64(time.Hour))))
+	return &t
+}
+
+// assertGolden compares got with the named file in testdata, rewriting the
+// file instead when -update is passed
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(expected, got) {
+		t.Errorf("output does not match %s (run with -update to accept)\n--- expected\n%s\n--- got\n%s", path, expected, got)
+	}
+}
+
+func metric(allocatable string, utilization string) *structs.ResourceMetric {
+	return &structs.ResourceMetric{
+		Allocatable: resource.MustParse(allocatable),
+		Utilization: resource.MustParse(utilization),
+	}
+}
+
+func committed(r *structs.ResourceMetric, requests string, limits string) *structs.ResourceMetric {
+	r.Requests = resource.MustParse(requests)
+	r.Limits = resource.MustParse(limits)
+	return r
+}
+
+func healthyNode(name string, role string) *structs.NodeData {
+	return &structs.NodeData{
+		NodeName:     name,
+		MachineName:  name + "-machine",
+		MachinePhase: "Running",
+		Age:          "42d",
+		Roles:        []string{role},
+		Ready:        true,
+		Cpu:          committed(metric("4", "1200m"), "1800m", "3"),
+		Memory:       committed(metric("16Gi", "6Gi"), "8Gi", "12Gi"),
+		Pods:         metric("250", "42"),
+	}
+}
+
+func clusterVersion(current string, desired string) *v1.ClusterVersion {
+	completed := metav1.NewTime(time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC))
+	cv := &v1.ClusterVersion{
+		Status: v1.ClusterVersionStatus{
+			History: []v1.UpdateHistory{
+				{State: v1.CompletedUpdate, Version: current, CompletionTime: &completed},
+			},
+		},
+	}
+	if desired != "" {
+		cv.Spec.DesiredUpdate = &v1.Update{Version: desired}
+	}
+	return cv
+}
+
+func clusterOperator(name string, available v1.ConditionStatus, degraded v1.ConditionStatus) v1.ClusterOperator {
+	return v1.ClusterOperator{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: v1.ClusterOperatorStatus{
+			Conditions: []v1.ClusterOperatorStatusCondition{
+				{Type: v1.OperatorAvailable, Status: available},
+				{Type: v1.OperatorDegraded, Status: degraded},
+			},
+		},
+	}
+}
+
+var goldenTableTests = []struct {
+	name string
+	opts Options
+	wide bool
+	cd   func() *structs.ClusterData
+}{
+	{
+		name: "healthy",
+		opts: Options{ShowUsage: true},
+		cd: func() *structs.ClusterData {
+			return &structs.ClusterData{
+				Nodes: []*structs.NodeData{
+					healthyNode("worker-0", "worker"),
+					healthyNode("master-0", "master"),
+					healthyNode("infra-0", "infra"),
+					{NodeName: "custom-0", Age: "3h", Roles: []string{"gpu"}, Ready: true},
+				},
+				Version: clusterVersion("4.13.4", ""),
+			}
+		},
+	},
+	{
+		name: "missing-and-failed-machines",
+		opts: Options{ShowUsage: true},
+		cd: func() *structs.ClusterData {
+			failed := healthyNode("worker-1", "worker")
+			failed.MachinePhase = "Failed"
+			failed.Ready = false
+			return &structs.ClusterData{
+				Nodes: []*structs.NodeData{
+					healthyNode("worker-0", "worker"),
+					failed,
+					{MachineName: "worker-2-machine", MachinePhase: "Provisioning"},
+					{MachineName: "worker-3-machine", MachinePhase: "Failed"},
+					{MachineName: "worker-4-machine", MachinePhase: "Deleting"},
+				},
+			}
+		},
+	},
+	{
+		name: "hot-and-under-pressure",
+		opts: Options{ShowUsage: true},
+		cd: func() *structs.ClusterData {
+			hotCpu := healthyNode("worker-0", "worker")
+			hotCpu.Cpu = metric("4", "3900m")
+			hotMemory := healthyNode("worker-1", "worker")
+			hotMemory.Memory = metric("16Gi", "15Gi")
+			hotMemory.MemoryPressure = true
+			disk := healthyNode("worker-2", "worker")
+			disk.DiskPressure = true
+			hotPods := healthyNode("worker-3", "worker")
+			hotPods.Pods = metric("250", "246")
+			overcommittedIdle := healthyNode("worker-4", "worker")
+			overcommittedIdle.Cpu = committed(metric("4", "200m"), "3900m", "8")
+			busyUnderRequested := healthyNode("worker-5", "worker")
+			busyUnderRequested.Cpu = committed(metric("4", "3700m"), "400m", "0")
+			return &structs.ClusterData{
+				Nodes: []*structs.NodeData{hotCpu, hotMemory, disk, hotPods, overcommittedIdle, busyUnderRequested},
+			}
+		},
+	},
+	{
+		name: "role-thresholds",
+		opts: Options{ShowUsage: true, Thresholds: config.ThresholdConfig{
+			Thresholds: config.Thresholds{Memory: 30},
+			Roles:      map[string]config.Thresholds{"master": {CPU: 25}, "worker": {Pods: 95}},
+		}},
+		cd: func() *structs.ClusterData {
+			return &structs.ClusterData{
+				Nodes: []*structs.NodeData{healthyNode("master-0", "master"), healthyNode("worker-0", "worker")},
+			}
+		},
+	},
+	{
+		name: "plain",
+		opts: Options{ShowUsage: true, ShowPools: true, ShowKeys: true, Theme: themes[ThemeASCII]},
+		cd: func() *structs.ClusterData {
+			notReady := healthyNode("worker-0", "worker")
+			notReady.Ready = false
+			notReady.Cordoned = true
+			notReady.Updating = true
+			notReady.Pool = "worker"
+			notReady.MCDState = "Degraded"
+			notReady.MCDReason = "failed to drain node"
+			hot := healthyNode("master-0", "master")
+			hot.Cpu = metric("4", "3900m")
+			hot.MemoryPressure = true
+			return &structs.ClusterData{
+				Nodes: []*structs.NodeData{
+					hot,
+					notReady,
+					{MachineName: "worker-1-machine", MachinePhase: "Failed"},
+				},
+				Version: clusterVersion("4.13.4", "4.13.5"),
+				Pools:   []*structs.PoolData{{Name: "worker", MachineCount: 2, UpdatedMachineCount: 1, Degraded: true, DegradedMessage: "node worker-0 is reporting: failed to drain node"}},
+			}
+		},
+	},
+	{
+		name: "upgrade-in-progress",
+		opts: Options{ShowUsage: false, ShowPools: true},
+		cd: func() *structs.ClusterData {
+			master := healthyNode("master-0", "master")
+			master.Pool = "master"
+			master.MCDState = "Done"
+			updating := healthyNode("worker-0", "worker")
+			updating.Updating = true
+			updating.Cordoned = true
+			updating.Ready = false
+			updating.Pool = "worker"
+			updating.MCDState = "Working"
+			degraded := healthyNode("worker-1", "worker")
+			degraded.Pool = "worker"
+			degraded.MCDState = "Degraded"
+			degraded.MCDReason = "failed to drain node: timed out waiting for pod eviction"
+			return &structs.ClusterData{
+				Nodes: []*structs.NodeData{master, updating, degraded},
+				Pools: []*structs.PoolData{
+					{Name: "master", MachineCount: 3, UpdatedMachineCount: 3, ReadyMachineCount: 3},
+					{Name: "worker", MachineCount: 5, UpdatedMachineCount: 2, ReadyMachineCount: 3,
+						DegradedMachineCount: 1, Updating: true, Degraded: true,
+						DegradedMessage: "Node worker-1 is reporting: failed to drain node"},
+					{Name: "infra", MachineCount: 2, UpdatedMachineCount: 0, ReadyMachineCount: 2, Paused: true},
+				},
+				Version: clusterVersion("4.12.20", "4.13.4"),
+				ClusterOperators: &v1.ClusterOperatorList{Items: []v1.ClusterOperator{
+					clusterOperator("dns", v1.ConditionTrue, v1.ConditionFalse),
+					clusterOperator("machine-config", v1.ConditionTrue, v1.ConditionTrue),
+					clusterOperator("network", v1.ConditionFalse, v1.ConditionTrue),
+				}},
+			}
+		},
+	},
+	{
+		name: "upgrade-details",
+		opts: Options{ShowUpgradeDetails: true},
+		cd: func() *structs.ClusterData {
+			cv := &v1.ClusterVersion{
+				Spec: v1.ClusterVersionSpec{
+					Channel:       "stable-4.13",
+					DesiredUpdate: &v1.Update{Version: "4.13.4"},
+				},
+				Status: v1.ClusterVersionStatus{
+					Desired: v1.Release{Version: "4.13.4"},
+					Conditions: []v1.ClusterOperatorStatusCondition{
+						{Type: v1.OperatorAvailable, Status: v1.ConditionTrue, LastTransitionTime: *at(700),
+							Message: "Done applying 4.12.20"},
+						{Type: v1.OperatorProgressing, Status: v1.ConditionTrue, LastTransitionTime: *at(3.5),
+							Message: "Working towards 4.13.4: 650 of 830 done (78% complete)"},
+						{Type: "Failing", Status: v1.ConditionFalse, LastTransitionTime: *at(3.5)},
+					},
+					History: []v1.UpdateHistory{
+						{State: v1.PartialUpdate, Version: "4.13.4", StartedTime: *at(3.5), Verified: true},
+						{State: v1.CompletedUpdate, Version: "4.12.20", StartedTime: *at(701), CompletionTime: at(700), Verified: true},
+						{State: v1.PartialUpdate, Version: "4.12.19", StartedTime: *at(1500), CompletionTime: at(1499)},
+						{State: v1.CompletedUpdate, Version: "4.12.18", StartedTime: *at(2000), CompletionTime: at(1998.75), Verified: true},
+					},
+					AvailableUpdates: []v1.Release{{Version: "4.13.5"}, {Version: "4.13.6"}},
+					ConditionalUpdates: []v1.ConditionalUpdate{
+						{
+							Release: v1.Release{Version: "4.13.7"},
+							Risks: []v1.ConditionalUpdateRisk{
+								{Name: "AWSOldBootImages", Message: "Clusters born before 4.9 may fail to scale up.",
+									URL: "https://issues.redhat.com/browse/OCPBUGS-1"},
+							},
+						},
+					},
+				},
+			}
+			return &structs.ClusterData{Version: cv}
+		},
+	},
+	{
+		name: "unavailable-sources",
+		opts: Options{ShowUsage: true},
+		cd: func() *structs.ClusterData {
+			// worker-0 names its machine in an annotation, worker-1 does not
+			withoutMachine := func(n *structs.NodeData) *structs.NodeData {
+				n.MachineName = ""
+				return n
+			}
+			cd := &structs.ClusterData{
+				Nodes: []*structs.NodeData{
+					healthyNode("worker-0", "worker"),
+					withoutMachine(healthyNode("worker-1", "worker")),
+				},
+			}
+			for _, n := range cd.Nodes {
+				n.MachinePhase = ""
+			}
+			cd.Warnings = []structs.Warning{
+				{Source: structs.SourceMachines, Message: "the server could not find the requested resource"},
+				{Source: structs.SourceNodeMetrics, Message: "the server is currently unable to handle the request"},
+			}
+			return cd
+		},
+	},
+	{
+		name: "grouped-by-machineset",
+		opts: Options{ShowUsage: true, GroupBy: structs.GroupByMachineSet},
+		cd: func() *structs.ClusterData {
+			inSet := func(n *structs.NodeData, machineSet string) *structs.NodeData {
+				n.MachineSet = machineSet
+				return n
+			}
+			cd := &structs.ClusterData{
+				Nodes: []*structs.NodeData{
+					healthyNode("master-0", "master"),
+					inSet(healthyNode("worker-a-0", "worker"), "worker-a"),
+					inSet(healthyNode("worker-b-0", "worker"), "worker-b"),
+					inSet(healthyNode("worker-a-1", "worker"), "worker-a"),
+					inSet(&structs.NodeData{MachineName: "worker-b-1-machine", MachinePhase: "Provisioning"}, "worker-b"),
+					healthyNode("master-1", "master"),
+					healthyNode("worker-manual-0", "worker"),
+				},
+			}
+			cd.AddMachineSets([]*structs.MachineSetData{
+				{Name: "worker-b", Desired: 2, Current: 2, Ready: 1, Available: 1},
+				{Name: "worker-a", Desired: 2, Current: 2, Ready: 2, Available: 2},
+				{Name: "worker-gpu"},
+			})
+			return cd
+		},
+	},
+	{
+		name: "grouped-by-zone",
+		opts: Options{ShowTopology: true, GroupBy: structs.GroupByZone},
+		cd: func() *structs.ClusterData {
+			inZone := func(n *structs.NodeData, zone string) *structs.NodeData {
+				n.Zone = zone
+				n.Region = "us-east-1"
+				n.InstanceType = "m6i.xlarge"
+				return n
+			}
+			notReady := inZone(healthyNode("worker-1", "worker"), "us-east-1b")
+			notReady.Ready = false
+			return &structs.ClusterData{
+				Nodes: []*structs.NodeData{
+					inZone(healthyNode("master-0", "master"), "us-east-1a"),
+					inZone(healthyNode("worker-0", "worker"), "us-east-1a"),
+					inZone(healthyNode("master-1", "master"), "us-east-1b"),
+					notReady,
+					inZone(healthyNode("master-2", "master"), "us-east-1c"),
+					inZone(&structs.NodeData{MachineName: "worker-2-machine", MachinePhase: "Provisioning"}, "us-east-1c"),
+					{NodeName: "bare-metal-0", Age: "3h", Roles: []string{"worker"}, Ready: true},
+				},
+			}
+		},
+	},
+	{
+		name: "wide-machine-problems",
+		wide: true,
+		cd: func() *structs.ClusterData {
+			withSystemInfo := func(n *structs.NodeData, kubelet string, osImage string, kernel string) *structs.NodeData {
+				n.InternalIP = "10.0.128.10"
+				n.KubeletVersion = kubelet
+				n.OSImage = osImage
+				n.KernelVersion = kernel
+				n.RuntimeVersion = "cri-o://1.26.3-3.rhaos4.13.git641290e.el9"
+				n.InstanceState = "running"
+				return n
+			}
+			running := withSystemInfo(healthyNode("worker-0", "worker"), "v1.26.5+7d22122",
+				"Red Hat Enterprise Linux CoreOS 413.92.202306141213-0 (Plow)", "5.14.0-284.18.1.el9_2.x86_64")
+			running.ProviderID = "aws:///us-east-1a/i-0a1b2c3d4e5f60001"
+			running.ExternalIP = "52.0.0.10"
+			notUpdated := withSystemInfo(healthyNode("worker-4", "worker"), "v1.25.10+8c21020",
+				"Red Hat Enterprise Linux CoreOS 412.86.202306132230-0 (Ootpa)", "4.18.0-372.59.1.el8_6.x86_64")
+			notUpdated.InternalIP = "10.0.128.14"
+			return &structs.ClusterData{
+				Nodes: []*structs.NodeData{
+					running,
+					notUpdated,
+					{
+						MachineName:   "worker-1-machine",
+						MachinePhase:  "Failed",
+						InstanceState: "unknown",
+						MachineError: &structs.MachineError{
+							Reason:  "InsufficientResources",
+							Message: "quota exceeded in us-east-1a",
+						},
+						MachineConditions: []structs.MachineCondition{
+							{Type: "InstanceExists", Status: "False", Reason: "ErrorCheckingProvider", Message: "VcpuLimitExceeded"},
+						},
+					},
+					{
+						MachineName:   "worker-2-machine",
+						MachinePhase:  "Provisioned",
+						InstanceState: "pending",
+						ProviderID:    "aws:///us-east-1b/i-0a1b2c3d4e5f60002",
+						Created:       *at(2),
+					},
+					{MachineName: "worker-3-machine", MachinePhase: "Provisioning", Created: *at(0.1)},
+				},
+			}
+		},
+	},
+	{
+		name: "keys",
+		opts: Options{ShowKeys: true},
+		cd: func() *structs.ClusterData {
+			return &structs.ClusterData{}
+		},
+	},
+}
+
+func TestTableRendererGolden(t *testing.T) {
+	for _, test := range goldenTableTests {
+		t.Run(test.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+			// colours are disabled so golden files are deterministic
+			opts := test.opts
+			opts.NoColor = true
+			r := NewTableRenderer(opts)
+			r.Wide = test.wide
+			r.now = func() time.Time { return goldenNow }
+			if err := r.Render(test.cd(), out); err != nil {
+				t.Fatal(err)
+			}
+			assertGolden(t, "table-"+test.name, out.Bytes())
+		})
+	}
+}
+
+// operatorWithConditions returns an operator at the given version whose
+// conditions last changed at the given times
+func operatorWithConditions(name string, version string, conditions ...v1.ClusterOperatorStatusCondition) v1.ClusterOperator {
+	return v1.ClusterOperator{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: v1.ClusterOperatorStatus{
+			Versions:   []v1.OperandVersion{{Name: "operator", Version: version}},
+			Conditions: conditions,
+		},
+	}
+}
+
+func condition(conditionType v1.ClusterStatusConditionType, status v1.ConditionStatus, hoursBeforeNow float64, message string) v1.ClusterOperatorStatusCondition {
+	return v1.ClusterOperatorStatusCondition{Type: conditionType, Status: status, LastTransitionTime: *at(hoursBeforeNow), Message: message}
+}
+
+func TestOperatorsTableRendererGolden(t *testing.T) {
+	cd := &structs.ClusterData{ClusterOperators: &v1.ClusterOperatorList{Items: []v1.ClusterOperator{
+		operatorWithConditions("network", "4.13.1",
+			condition(v1.OperatorAvailable, v1.ConditionTrue, 240, "All is well"),
+			condition(v1.OperatorProgressing, v1.ConditionTrue, 3, "Deployment \"ovnkube-node\" is rolling out"),
+			condition(v1.OperatorDegraded, v1.ConditionFalse, 240, ""),
+		),
+		operatorWithConditions("dns", "4.13.1",
+			condition(v1.OperatorAvailable, v1.ConditionTrue, 240, "DNS default is available"),
+			condition(v1.OperatorProgressing, v1.ConditionFalse, 240, ""),
+			condition(v1.OperatorDegraded, v1.ConditionFalse, 240, ""),
+		),
+		operatorWithConditions("authentication", "4.13.1",
+			condition(v1.OperatorAvailable, v1.ConditionFalse, 0.5, "OAuthServerDeployment has no pods available"),
+			condition(v1.OperatorDegraded, v1.ConditionTrue, 0.5, "OAuthServerDeploymentDegraded"),
+		),
+		operatorWithConditions("machine-config", "4.13.0",
+			condition(v1.OperatorAvailable, v1.ConditionTrue, 48, "Cluster has deployed 4.13.0"),
+			condition(v1.OperatorUpgradeable, v1.ConditionFalse, 2, "One or more machine config pools are degraded"),
+		),
+		operatorWithConditions("ingress", "4.13.1",
+			condition(v1.OperatorAvailable, v1.ConditionTrue, 240, "The ingress controller is available"),
+			condition(v1.OperatorProgressing, v1.ConditionTrue, 0.1, "Ingress controller is scaling"),
+		),
+	}}}
+
+	out := new(bytes.Buffer)
+	r := &OperatorsTableRenderer{Theme: themes[ThemeEmoji].uncolored(), now: func() time.Time { return goldenNow }}
+	if err := r.Render(cd, out); err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "operators-table", out.Bytes())
+}
@@ -0,0 +1,138 @@
+package outputter
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"nodepp/internal/structs"
+)
+
+const (
+	OperatorsDocumentKind = "ClusterOperators"
+
+	// operatorProgressingTooLong is how long an operator may progress before
+	// it is highlighted as likely to block an upgrade
+	operatorProgressingTooLong = 30 * time.Minute
+)
+
+// OperatorsDocument is the machine-readable form of the operators view
+type OperatorsDocument struct {
+	APIVersion string                    `json:"apiVersion"`
+	Kind       string                    `json:"kind"`
+	Operators  []*structs.OperatorStatus `json:"operators"`
+	Warnings   []structs.Warning         `json:"warnings,omitempty"`
+}
+
+// NewOperatorsRenderer returns the renderer for the operators view in the
+// given output format
+func NewOperatorsRenderer(format string, opts Options) (Renderer, error) {
+	switch format {
+	case FormatTable:
+		return &OperatorsTableRenderer{Theme: opts.theme(), now: time.Now}, nil
+	case FormatJSON, FormatYAML:
+		return &OperatorsDocumentRenderer{Format: format}, nil
+	}
+	return nil, fmt.Errorf("unsupported output format %q", format)
+}
+
+// operatorStatuses returns the status of every operator, sorted by name
+func operatorStatuses(cd *structs.ClusterData) []*structs.OperatorStatus {
+	statuses := cd.OperatorStatuses()
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// OperatorsDocumentRenderer serialises every cluster operator as a versioned JSON or YAML document
+type OperatorsDocumentRenderer struct {
+	Format string
+}
+
+func (d *OperatorsDocumentRenderer) Render(cd *structs.ClusterData, w io.Writer) error {
+	return encodeDocument(w, d.Format, &OperatorsDocument{
+		APIVersion: DocumentAPIVersion,
+		Kind:       OperatorsDocumentKind,
+		Operators:  operatorStatuses(cd),
+		Warnings:   cd.Warnings,
+	})
+}
+
+// OperatorsTableRenderer renders every cluster operator and its conditions as a table
+type OperatorsTableRenderer struct {
+	Theme Theme
+
+	// now returns the time that relative durations are measured from
+	now func() time.Time
+}
+
+func (o *OperatorsTableRenderer) Render(cd *structs.ClusterData, w io.Writer) error {
+	operatorTable := table.NewWriter()
+	operatorTable.SetStyle(o.Theme.Style)
+	operatorTable.Style().Color.Footer = o.Theme.Colors.Footer
+
+	operatorTable.AppendHeader(table.Row{" ", "NAME", "VERSION", "AVAILABLE", "PROGRESSING", "DEGRADED", "UPGRADEABLE", "SINCE", "MESSAGE"})
+	now := o.now()
+	for _, status := range operatorStatuses(cd) {
+		operatorTable.AppendRow(o.makeRow(status, now))
+	}
+	operatorTable.AppendFooter(table.Row{""})
+
+	fmt.Fprintln(w, operatorTable.Render())
+	showWarnings(w, cd, o.Theme)
+	return nil
+}
+
+func (o *OperatorsTableRenderer) makeRow(status *structs.OperatorStatus, now time.Time) table.Row {
+	since := ""
+	if !status.Since.IsZero() {
+		since = duration.HumanDuration(now.Sub(status.Since.Time))
+	}
+
+	// operators progressing for a long time or refusing upgrades block
+	// cluster updates, so flag them alongside the broken ones
+	blocking := !status.Upgradeable ||
+		(status.ProgressingSince != nil && now.Sub(status.ProgressingSince.Time) > operatorProgressingTooLong)
+
+	var marker string
+	colour := text.Colors{}
+	switch {
+	case status.Down || status.Degraded:
+		marker = o.Theme.Symbols.Critical
+		colour = o.Theme.Colors.Critical
+	case blocking:
+		marker = o.Theme.Symbols.Warning
+		colour = o.Theme.Colors.Warning
+	}
+
+	row := table.Row{marker}
+	for _, cell := range []string{
+		status.Name,
+		status.Version,
+		conditionValue(!status.Down),
+		conditionValue(status.Progressing),
+		conditionValue(status.Degraded),
+		conditionValue(status.Upgradeable),
+		since,
+		status.Message,
+	} {
+		if len(colour) > 0 {
+			cell = colour.Sprint(cell)
+		}
+		row = append(row, cell)
+	}
+	return row
+}
+
+func conditionValue(value bool) string {
+	if value {
+		return "True"
+	}
+	return "False"
+}
@@ -3,216 +3,535 @@ package outputter
 import (
 	"fmt"
 	"github.com/jedib0t/go-pretty/v6/text"
-	v1 "github.com/openshift/api/config/v1"
 	"io"
 	"nodepp/internal/structs"
 	"nodepp/internal/util"
+	"strings"
+	"time"
 
 	"github.com/jedib0t/go-pretty/v6/table"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
 
+	"nodepp/internal/config"
 	"nodepp/internal/consts"
 )
 
-type Outputter struct {
-	ShowUsage   bool
-	NodeMetrics *structs.ClusterData
+// TableRenderer renders the cluster data as an at-a-glance emoji table
+type TableRenderer struct {
+	ShowUsage          bool
+	ShowPools          bool
+	ShowUpgradeDetails bool
+	ShowTopology       bool
+	ShowKeys           bool
+	HighlightChanges   bool
+	Thresholds         config.ThresholdConfig
+	SortBy             []structs.SortKey
+	GroupBy            string
+	Theme              Theme
+
+	// Wide adds columns describing each machine's provider instance
+	Wide bool
+
+	// CustomColumns are added after the built-in columns
+	CustomColumns []CustomColumn
+
+	// now returns the time that relative durations are measured from
+	now func() time.Time
+
+	// previous holds the state of each row from the last render, keyed by row identity
+	previous map[string]string
+
+	// objects holds the row objects converted for custom columns
+	objects objectCache
 }
 
-type tableRow struct {
-	ready       string
-	nodeName    string
-	machineName string
-	internalIP  string
-	nodeRole    string
-	age         string
-	status      string
-	cpu         string
-	memory      string
+// NewTableRenderer returns a table renderer using the theme from the options
+func NewTableRenderer(opts Options) *TableRenderer {
+	return &TableRenderer{
+		ShowUsage:          opts.ShowUsage,
+		ShowPools:          opts.ShowPools,
+		ShowUpgradeDetails: opts.ShowUpgradeDetails,
+		ShowTopology:       opts.ShowTopology,
+		now:                time.Now,
+		ShowKeys:           opts.ShowKeys,
+		HighlightChanges:   opts.HighlightChanges,
+		Thresholds:         opts.Thresholds,
+		SortBy:             opts.SortBy,
+		GroupBy:            opts.GroupBy,
+		CustomColumns:      opts.CustomColumns,
+		Theme:              opts.theme(),
+	}
 }
 
-var tableHeader = tableRow{
-	ready:       " ",
-	nodeName:    "NODE",
-	machineName: "MACHINE",
-	nodeRole:    "ROLE",
-	age:         "AGE",
-	status:      "STATUS",
-	cpu:         "CPU",
-	memory:      "MEMORY",
+const (
+	// unavailable marks values whose data source could not be retrieved
+	unavailable = "unavailable"
+)
+
+func (o *TableRenderer) Render(cd *structs.ClusterData, w io.Writer) error {
+	header := o.makeHeaderRow()
+
+	cd.Sort(sortKeys(o.SortBy))
+	current := make(map[string]string, len(cd.Nodes))
+	nodeRows := func(nodes []*structs.NodeData) []table.Row {
+		var tableRows []table.Row
+		for _, node := range nodes {
+			key, state := rowIdentity(node), rowState(node)
+			current[key] = state
+			changed := o.HighlightChanges && o.previous != nil && o.previous[key] != state
+
+			rows := o.makeRows(cd, node)
+			for _, r := range rows {
+				if changed {
+					r = highlightRow(r, o.Theme.Symbols.Changed, o.Theme.Colors.Changed)
+				}
+				tableRows = append(tableRows, r)
+			}
+		}
+		return tableRows
+	}
+
+	groups, summarise := o.groups(cd)
+	if groups == nil {
+		fmt.Fprintln(w, o.renderTable(header, nodeRows(cd.Nodes), nil))
+	} else {
+		groupRows := make([][]table.Row, len(groups))
+		for i, group := range groups {
+			groupRows[i] = nodeRows(group.Nodes)
+		}
+		// every group's table has the same column widths so they line up
+		widths := columnWidths(header, groupRows)
+		for i, group := range groups {
+			fmt.Fprintln(w, summarise(group))
+			if len(groupRows[i]) == 0 {
+				// scaled down sets have no rows to show
+				fmt.Fprintln(w)
+				continue
+			}
+			fmt.Fprintln(w, o.renderTable(header, groupRows[i], widths))
+		}
+	}
+	o.previous = current
+
+	showVersion(w, cd, o.Theme)
+	if o.ShowUpgradeDetails {
+		showUpgradeDetails(w, cd, o.Theme, o.now())
+	}
+	showClusterOperators(w, cd, o.Theme)
+	if o.ShowPools {
+		showPools(w, cd, o.Theme)
+	}
+	showMachineProblems(w, cd, o.Theme, o.now())
+	showWarnings(w, cd, o.Theme)
+	if o.ShowKeys {
+		printKeys(w, o.Theme.Symbols, o.HighlightChanges)
+	}
+	return nil
 }
 
-func (o *Outputter) Print() {
+// renderTable draws the node table, padding columns to the given minimum widths if set
+func (o *TableRenderer) renderTable(header table.Row, rows []table.Row, widths []int) string {
 	nodeTable := table.NewWriter()
-	nodeTable.SetStyle(table.StyleColoredDark)
-	nodeTable.Style().Color.Footer = text.Colors{text.FgHiYellow, text.BgHiBlack}
+	nodeTable.SetStyle(o.Theme.Style)
+	nodeTable.Style().Color.Footer = o.Theme.Colors.Footer
 	rowConfigAutoMerge := table.RowConfig{AutoMerge: false}
 
-	header := o.makeHeaderRow()
 	nodeTable.AppendHeader(header, rowConfigAutoMerge)
+	for _, r := range rows {
+		nodeTable.AppendRow(r, rowConfigAutoMerge)
+	}
+	nodeTable.AppendFooter(table.Row{""})
 
-	o.NodeMetrics.SortByRole()
-	for _, node := range o.NodeMetrics.Nodes {
-		rows := o.makeRows(node)
+	if widths != nil {
+		configs := make([]table.ColumnConfig, len(widths))
+		for i, width := range widths {
+			configs[i] = table.ColumnConfig{Number: i + 1, WidthMin: width}
+		}
+		nodeTable.SetColumnConfigs(configs)
+	}
+	return nodeTable.Render()
+}
+
+// columnWidths returns the display width of the widest cell in each column
+// across several tables
+func columnWidths(header table.Row, tables [][]table.Row) []int {
+	widths := make([]int, len(header))
+	measure := func(r table.Row) {
+		for i, cell := range r {
+			if width := text.RuneWidthWithoutEscSequences(fmt.Sprint(cell)); i < len(widths) && width > widths[i] {
+				widths[i] = width
+			}
+		}
+	}
+	measure(header)
+	for _, rows := range tables {
 		for _, r := range rows {
-			nodeTable.AppendRow(r, rowConfigAutoMerge)
+			measure(r)
 		}
 	}
-	nodeTable.AppendFooter(table.Row{""})
+	return widths
+}
 
-	fmt.Println(nodeTable.Render())
-	o.showVersion()
-	o.showClusterOperators()
+// groups splits the rows into the groups selected by GroupBy, and returns
+// the function that summarises each group. Rows are not grouped if GroupBy
+// is unset.
+func (o *TableRenderer) groups(cd *structs.ClusterData) ([]*structs.RowGroup, func(*structs.RowGroup) string) {
+	switch o.GroupBy {
+	case structs.GroupByMachineSet:
+		return cd.GroupByMachineSet(), func(group *structs.RowGroup) string {
+			return o.machineSetSummary(cd, group)
+		}
+	case structs.GroupByZone:
+		groups := cd.GroupByZone()
+		largest := 0
+		for _, group := range groups {
+			if group.Name != "" && group.NodeCount() > largest {
+				largest = group.NodeCount()
+			}
+		}
+		return groups, func(group *structs.RowGroup) string {
+			return o.zoneSummary(group, largest)
+		}
+	}
+	return nil, nil
 }
 
-func (o *Outputter) PrintRow(w io.Writer) {
+// zoneSummary counts the nodes in a zone and how many are ready, flagging
+// zones that have fewer nodes than the largest zone
+func (o *TableRenderer) zoneSummary(group *structs.RowGroup, largest int) string {
+	s := o.Theme.Symbols
+	if group.Name == "" {
+		return o.Theme.Colors.Heading.Sprintf(" %s", label(s.Warning, "No zone"))
+	}
+
+	name := group.Name
+	if region := group.Nodes[0].Region; region != "" {
+		name += " (" + region + ")"
+	}
+	nodes, ready := group.NodeCount(), group.ReadyCount()
+	summary := fmt.Sprintf("Zone %s: %s, %d ready", name, plural(nodes, "node"), ready)
+	if missing := len(group.Nodes) - nodes; missing > 0 {
+		summary += fmt.Sprintf(", %s without a node", plural(missing, "machine"))
+	}
+
+	var problems []string
+	if ready < nodes {
+		problems = append(problems, fmt.Sprintf("%d not ready", nodes-ready))
+	}
+	if nodes < largest {
+		problems = append(problems, fmt.Sprintf("%d fewer than the largest zone", largest-nodes))
+	}
+	if len(problems) > 0 {
+		summary = label(s.Warning, summary) + " (" + strings.Join(problems, ", ") + ")"
+	}
+	return o.Theme.Colors.Heading.Sprintf(" %s", summary)
+}
+
+// plural counts things, pluralising the noun unless there is exactly one
+func plural(count int, noun string) string {
+	if count == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
+
+// machineSetSummary describes the replicas of a MachineSet group, flagging
+// sets that are scaled down or short of ready replicas, and worker rows that
+// no MachineSet owns
+func (o *TableRenderer) machineSetSummary(cd *structs.ClusterData, group *structs.RowGroup) string {
+	s := o.Theme.Symbols
+	if group.ControlPlane {
+		return o.Theme.Colors.Heading.Sprintf(" Control plane: %s, %d ready", plural(group.NodeCount(), "node"), group.ReadyCount())
+	}
+	if group.Name == "" {
+		return o.Theme.Colors.Heading.Sprintf(" %s", label(s.Warning, "No MachineSet: orphaned machines and nodes"))
+	}
+
+	ms := group.MachineSet
+	if ms == nil {
+		state := "not found"
+		if cd.Unavailable(structs.SourceMachineSets) {
+			state = unavailable
+		}
+		return o.Theme.Colors.Heading.Sprintf(" MachineSet %s: %s", group.Name, state)
+	}
+
+	summary := fmt.Sprintf("MachineSet %s: %d desired, %d current, %d ready, %d available",
+		ms.Name, ms.Desired, ms.Current, ms.Ready, ms.Available)
+	switch {
+	case ms.ErrorReason != "":
+		summary = label(s.Failed, summary) + fmt.Sprintf(" (%s: %s)", ms.ErrorReason, ms.ErrorMessage)
+	case ms.ScaledDown():
+		summary += " (scaled down)"
+	case ms.Behind():
+		summary = label(s.Provisioning, summary) + fmt.Sprintf(" (%d not ready)", ms.Desired-ms.Ready)
+	}
+	return o.Theme.Colors.Heading.Sprintf(" %s", summary)
 }
 
-func (o *Outputter) makeHeaderRow() table.Row {
-	r := table.Row{
-		tableHeader.ready,
-		tableHeader.nodeName,
-		tableHeader.machineName,
-		tableHeader.nodeRole,
-		tableHeader.age,
-		tableHeader.status,
+// rowIdentity returns a key that identifies the same row across refreshes
+func rowIdentity(n *structs.NodeData) string {
+	if n.NodeName != "" {
+		return "node/" + n.NodeName
 	}
-	if o.ShowUsage {
-		r = append(r, tableHeader.cpu, tableHeader.memory)
+	return "machine/" + n.MachineName
+}
+
+// rowState summarises the status of a row, ignoring values such as age and
+// utilization that drift on every refresh
+func rowState(n *structs.NodeData) string {
+	return fmt.Sprintf("%s|%s|%v|%s|%s|%t|%t|%t|%t|%t|%t",
+		n.MachineName, n.MachinePhase, n.Roles, n.Pool, n.MCDState, n.Updating,
+		n.Missing, n.Cordoned, n.Ready, n.MemoryPressure, n.DiskPressure)
+}
+
+// highlightRow marks the first cell of a row and colours every cell to draw
+// attention to it. The marker shows the change when colours are disabled.
+func highlightRow(r table.Row, marker string, colors text.Colors) table.Row {
+	highlighted := make(table.Row, len(r))
+	for i, cell := range r {
+		if i == 0 {
+			cell = label(marker, fmt.Sprint(cell))
+		}
+		highlighted[i] = colors.Sprint(cell)
+	}
+	return highlighted
+}
+
+func (o *TableRenderer) makeHeaderRow() table.Row {
+	var r table.Row
+	for _, c := range o.shownColumns() {
+		r = append(r, c.header)
 	}
 	return r
 }
 
-func (o *Outputter) showVersion() {
-	if o.NodeMetrics.Version == nil {
+func showVersion(w io.Writer, cd *structs.ClusterData, t Theme) {
+	s := t.Symbols
+	if cd.Version == nil {
 		return
 	}
-	vt := text.FgHiYellow.Sprintf(" %c Version: ", consts.EMOJI_GEAR)
-	current, err := util.GetCurrentVersion(o.NodeMetrics.Version)
+	vt := t.Colors.Heading.Sprintf(" %s ", label(s.Version, "Version:"))
+	current, err := util.GetCurrentVersion(cd.Version)
 	if err == nil {
-		vt += text.FgYellow.Sprintf(current)
-		desired := o.NodeMetrics.Version.Spec.DesiredUpdate
+		vt += t.Colors.Text.Sprintf(current)
+		desired := cd.Version.Spec.DesiredUpdate
 		if desired != nil {
-			vt += text.FgYellow.Sprintf("  %c  %s", consts.EMOJI_SOON, desired.Version)
+			vt += t.Colors.Text.Sprintf("  %s  %s", s.Desired, desired.Version)
 		}
 	}
-	fmt.Println(vt)
+	fmt.Fprintln(w, vt)
 }
 
-func (o *Outputter) showClusterOperators() {
+func showClusterOperators(w io.Writer, cd *structs.ClusterData, t Theme) {
+	s := t.Symbols
 
-	if o.NodeMetrics.ClusterOperators == nil {
+	if cd.ClusterOperators == nil {
 		return
 	}
 
 	operatorReport := ""
-	for _, co := range o.NodeMetrics.ClusterOperators.Items {
-		for _, cnd := range co.Status.Conditions {
-			if cnd.Type == v1.OperatorAvailable && cnd.Status == v1.ConditionFalse {
-				operatorReport += text.FgYellow.Sprintf(" %c %s (down)\n", consts.EMOJI_SIREN, co.Name)
-				break
+	for _, co := range cd.UnhealthyOperators() {
+		if co.Down {
+			operatorReport += t.Colors.Text.Sprintf(" %s (down)\n", label(s.Critical, co.Name))
+		} else if co.Degraded {
+			operatorReport += t.Colors.Text.Sprintf(" %s (degraded)\n", label(s.Warning, co.Name))
+		}
+	}
+	if operatorReport != "" {
+		fmt.Fprintln(w, t.Colors.Heading.Sprintf(" Unhealthy Cluster Operators:"))
+		fmt.Fprintln(w, operatorReport)
+	}
+}
+
+func showPools(w io.Writer, cd *structs.ClusterData, t Theme) {
+	s := t.Symbols
+	if len(cd.Pools) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, t.Colors.Heading.Sprintf(" Machine Config Pools:"))
+	for _, pool := range cd.Pools {
+		var state string
+		switch {
+		case pool.Degraded:
+			state = " " + label(s.Warning, "degraded")
+		case pool.Updating:
+			state = " " + label(s.Updating, "updating")
+		}
+		if pool.Paused {
+			state += " (paused)"
+		}
+		fmt.Fprintln(w, t.Colors.Text.Sprintf(" %s: %d/%d updated, %d/%d ready, %d degraded%s",
+			pool.Name, pool.UpdatedMachineCount, pool.MachineCount, pool.ReadyMachineCount,
+			pool.MachineCount, pool.DegradedMachineCount, state))
+	}
+	fmt.Fprintln(w)
+
+	degradedReport := ""
+	for _, pool := range cd.Pools {
+		if pool.Degraded && pool.DegradedMessage != "" {
+			degradedReport += t.Colors.Text.Sprintf(" %s: %s\n", label(s.Warning, "pool "+pool.Name), pool.DegradedMessage)
+		}
+	}
+	for _, n := range cd.Nodes {
+		if n.MCDState == consts.MachineConfigStateDegraded {
+			degradedReport += t.Colors.Text.Sprintf(" %s: %s\n", label(s.Warning, "node "+n.NodeName), n.MCDReason)
+		}
+	}
+	if degradedReport != "" {
+		fmt.Fprintln(w, t.Colors.Heading.Sprintf(" Degraded Machine Config:"))
+		fmt.Fprintln(w, degradedReport)
+	}
+}
+
+// showMachineProblems explains why machines have failed or are stuck
+// provisioning, from the machine's error, provider instance and conditions
+func showMachineProblems(w io.Writer, cd *structs.ClusterData, t Theme, now time.Time) {
+	s := t.Symbols
+	report := ""
+	for _, n := range cd.Nodes {
+		if !n.MachineProblem(now) {
+			continue
+		}
+		var problem string
+		switch {
+		case n.MachinePhase == "Failed":
+			problem = label(s.Failed, "machine "+n.MachineName) + ": Failed"
+		case n.MachineStuck(now):
+			problem = label(s.Provisioning, "machine "+n.MachineName) + fmt.Sprintf(": %s for %s",
+				n.MachinePhase, duration.HumanDuration(now.Sub(n.Created.Time)))
+		default:
+			problem = label(s.Warning, "machine "+n.MachineName) + ": " + n.MachinePhase
+		}
+		if n.MachineError != nil {
+			problem += fmt.Sprintf(", %s: %s", n.MachineError.Reason, n.MachineError.Message)
+		}
+		report += t.Colors.Text.Sprintf(" %s\n", problem)
+
+		var instance []string
+		if n.InstanceState != "" {
+			instance = append(instance, "instance state "+n.InstanceState)
+		}
+		if n.ProviderID != "" {
+			instance = append(instance, "provider ID "+n.ProviderID)
+		}
+		if len(instance) > 0 {
+			report += t.Colors.Text.Sprintf("     %s\n", strings.Join(instance, ", "))
+		}
+		for _, c := range n.MachineConditions {
+			if c.Status == string(corev1.ConditionTrue) {
+				continue
+			}
+			condition := fmt.Sprintf("%s=%s", c.Type, c.Status)
+			if c.Reason != "" {
+				condition += " (" + c.Reason + ")"
 			}
-			if cnd.Type == v1.OperatorDegraded && cnd.Status == v1.ConditionTrue {
-				operatorReport += text.FgYellow.Sprintf(" %c %s (degraded)\n", consts.EMOJI_WARN, co.Name)
+			if c.Message != "" {
+				condition += ": " + c.Message
 			}
+			report += t.Colors.Text.Sprintf("     %s\n", condition)
 		}
 	}
-	if operatorReport != "" {
-		fmt.Println(text.FgHiYellow.Sprintf(" Unhealthy Cluster Operators:"))
-		fmt.Println(operatorReport)
+	if report != "" {
+		fmt.Fprintln(w, t.Colors.Heading.Sprintf(" Machine Problems:"))
+		fmt.Fprintln(w, report)
 	}
 }
 
-func (o *Outputter) makeRows(n *structs.NodeData) []table.Row {
+func showWarnings(w io.Writer, cd *structs.ClusterData, t Theme) {
+	s := t.Symbols
+	if len(cd.Warnings) == 0 {
+		return
+	}
+	fmt.Fprintln(w, t.Colors.Heading.Sprintf(" Warnings:"))
+	for _, warning := range cd.Warnings {
+		fmt.Fprintln(w, t.Colors.Text.Sprintf(" %s unavailable: %s", label(s.Warning, warning.Source), warning.Message))
+	}
+	fmt.Fprintln(w)
+}
+
+func (o *TableRenderer) makeRows(cd *structs.ClusterData, n *structs.NodeData) []table.Row {
 
 	numRows := n.NumRows()
 	fields := make([]table.Row, numRows)
 
 	// First row
 	var row table.Row
-
-	// Ready
-	if !n.Ready {
-		row = append(row, fmt.Sprintf("%c", consts.EMOJI_SIREN))
-	} else {
-		row = append(row, "")
+	for _, c := range o.shownColumns() {
+		row = append(row, c.value(o, cd, n))
 	}
+	fields = append(fields, row)
 
-	// Node name
-	if n.NodeName == "" {
-		row = append(row, fmt.Sprintf("%c", consts.EMOJI_QUESTION))
-	} else {
-		row = append(row, n.NodeName)
+	return fields
+}
+
+// makeCpuValue shows the CPU used by a node and its share of allocatable
+func makeCpuValue(cd *structs.ClusterData, n *structs.NodeData, threshold float64, s Symbols) string {
+	if cd.Unavailable(structs.SourceNodeMetrics) {
+		return unavailable
+	}
+	if n.Cpu == nil {
+		return ""
+	}
+	utilFraction := n.Cpu.UtilizationPercent()
+	cpuval := fmt.Sprintf("%vm (%d%%)", n.Cpu.Utilization.MilliValue(), int64(utilFraction))
+	if utilFraction > threshold {
+		cpuval = s.mark(cpuval, s.Hot)
 	}
+	return cpuval
+}
 
-	// Machine name
-	row = append(row, n.MachineName)
+// makeMemoryValue shows the memory used by a node and its share of allocatable
+func makeMemoryValue(cd *structs.ClusterData, n *structs.NodeData, threshold float64, s Symbols) string {
+	if cd.Unavailable(structs.SourceNodeMetrics) {
+		return unavailable
+	}
+	if n.Memory == nil {
+		return ""
+	}
+	utilFraction := n.Memory.UtilizationPercent()
+	memval := fmt.Sprintf("%vMi (%d%%)", n.Memory.Utilization.Value()/(1024*1024), int64(utilFraction))
+	if utilFraction > threshold {
+		memval = s.mark(memval, s.Hot)
+	}
+	return memval
+}
 
-	// Role
-	if len(n.Roles) > 0 {
-		row = append(row, makeRoleValue(n.Roles))
-	} else {
-		row = append(row, "")
-	}
-
-	// Age
-	row = append(row, n.Age)
-
-	// Status
-	var status string
-	if n.Updating {
-		status += fmt.Sprintf("%c", consts.EMOJI_WRENCH)
-	}
-	if n.Cordoned {
-		status += fmt.Sprintf("%c", consts.EMOJI_ROADBLOCK)
-	}
-	switch n.MachinePhase {
-	case "Failed":
-		status += fmt.Sprintf("%c", consts.EMOJI_CROSS)
-	case "Deleting":
-		status += fmt.Sprintf("%c", consts.EMOJI_WASTE)
-	case "Provisioned":
-		status += fmt.Sprintf("%c", consts.EMOJI_UPARROW)
-	case "Provisioning":
-		status += fmt.Sprintf("%c", consts.EMOJI_UPARROW)
-	}
-	if n.MemoryPressure {
-		status += fmt.Sprintf("%c", consts.EMOJI_EXPLODE)
-	}
-	if n.DiskPressure {
-		status += fmt.Sprintf("%c", consts.EMOJI_DISK)
-	}
-	row = append(row, status)
-
-	// Usage
-	if o.ShowUsage {
-		// Show utilization and allocatable in first row
-		if n.Cpu != nil {
-			utilFraction := float64(n.Cpu.Utilization.MilliValue()) / float64(n.Cpu.Allocatable.MilliValue()) * 100
-			cpuval := fmt.Sprintf("%vm (%d%%)", n.Cpu.Utilization.MilliValue(), int64(utilFraction))
-			if utilFraction > 90 {
-				cpuval += string(consts.EMOJI_FIRE)
-			}
-			row = append(row, cpuval)
-		} else {
-			row = append(row, "")
-		}
-		if n.Memory != nil {
-			utilFraction := float64(n.Memory.Utilization.MilliValue()) / float64(n.Memory.Allocatable.MilliValue()) * 100
-			memval := fmt.Sprintf("%vMi (%d%%)", n.Memory.Utilization.Value()/(1024*1024), int64(utilFraction))
-			if utilFraction > 90 {
-				memval += string(consts.EMOJI_FIRE)
-			}
-			row = append(row, memval)
-		} else {
-			row = append(row, "")
-		}
+// makeCommitmentValue shows the sum of pod requests and limits on a node as a
+// share of allocatable, so it can be compared with live utilization
+func makeCommitmentValue(cd *structs.ClusterData, n *structs.NodeData, r *structs.ResourceMetric, threshold float64, s Symbols) string {
+	if cd.Unavailable(structs.SourcePods) {
+		return unavailable
 	}
-	fields = append(fields, row)
+	if n.NodeName == "" || r == nil {
+		return ""
+	}
+	requests := r.RequestsPercent()
+	commitval := fmt.Sprintf("%d%%/%d%%", int64(requests), int64(r.LimitsPercent()))
+	if requests > threshold {
+		commitval = s.mark(commitval, s.Hot)
+	}
+	return commitval
+}
 
-	return fields
+// makePodsValue shows the pods scheduled to a node against its pod capacity
+func makePodsValue(cd *structs.ClusterData, n *structs.NodeData, threshold float64, s Symbols) string {
+	if cd.Unavailable(structs.SourcePods) {
+		return unavailable
+	}
+	if n.NodeName == "" || n.Pods == nil {
+		return ""
+	}
+	podval := fmt.Sprintf("%d/%d", n.Pods.Utilization.Value(), n.Pods.Allocatable.Value())
+	if n.Pods.UtilizationPercent() > threshold {
+		podval = s.mark(podval, s.Hot)
+	}
+	return podval
 }
 
-func makeRoleValue(roles []string) string {
+func makeRoleValue(roles []string, s Symbols) string {
 	// handle no roles
 	if len(roles) == 0 {
 		return "-"
@@ -233,25 +552,77 @@ func makeRoleValue(roles []string) string {
 		}
 	}
 
+	// the building emoji renders narrower than the others, so it is padded
 	if foundMaster {
-		return fmt.Sprintf("%c  master", consts.EMOJI_BUILDING)
+		return roleLabel(s.Master, "  ", "master")
 	}
 	if foundInfra {
-		return fmt.Sprintf("%c infra", consts.EMOJI_BRICK)
+		return roleLabel(s.Infra, " ", "infra")
 	}
 	if foundWorker {
-		return fmt.Sprintf("%c worker", consts.EMOJI_WORKER)
+		return roleLabel(s.Worker, " ", "worker")
 	}
 
 	// just return the first role in the list
 	return roles[0]
 }
 
-func (o *Outputter) PrintKeys() {
-	fmt.Printf("%c  Master Node\t\t%c  Infra Node\t\t%c  Worker Node\t\t%c  Missing Node\t%c  Not Ready\n",
-		consts.EMOJI_BUILDING, consts.EMOJI_BRICK, consts.EMOJI_WORKER, consts.EMOJI_QUESTION, consts.EMOJI_SIREN)
-	fmt.Printf("%c  Cordoned\t\t%c  Updating\t\t%c  Failed\t\t%c  Deleting\t\t%c  Provisioning\n",
-		consts.EMOJI_ROADBLOCK, consts.EMOJI_WRENCH, consts.EMOJI_CROSS, consts.EMOJI_WASTE, consts.EMOJI_UPARROW)
-	fmt.Printf("%c  Disk Pressure\t%c  Memory Pressure\t%c  Resource is hot\n\n",
-		consts.EMOJI_DISK, consts.EMOJI_EXPLODE, consts.EMOJI_FIRE)
+func roleLabel(symbol string, padding string, role string) string {
+	if symbol == "" {
+		return role
+	}
+	return symbol + padding + role
+}
+
+// keysPerLine is the number of symbol keys printed on each line
+const keysPerLine = 5
+
+// printKeys prints the key to the theme's symbols, including the marker for
+// changed rows when changes are highlighted
+func printKeys(w io.Writer, s Symbols, changes bool) {
+	type symbolKey struct {
+		symbol string
+		name   string
+	}
+	keys := []symbolKey{
+		{s.Master, "Master Node"},
+		{s.Infra, "Infra Node"},
+		{s.Worker, "Worker Node"},
+		{s.Missing, "Missing Node"},
+		{s.NotReady, "Not Ready"},
+		{s.Cordoned, "Cordoned"},
+		{s.Updating, "Updating"},
+		{s.Failed, "Failed"},
+		{s.Deleting, "Deleting"},
+		{s.Provisioning, "Provisioning"},
+		{s.DiskPressure, "Disk Pressure"},
+		{s.MemoryPressure, "Memory Pressure"},
+		{s.Hot, "Resource is hot"},
+		{s.Warning, "Degraded"},
+	}
+	if changes {
+		keys = append(keys, symbolKey{s.Changed, "Changed"})
+	}
+
+	entries := make([]string, 0, len(keys))
+	for _, key := range keys {
+		// states without a symbol are shown by name alone, so need no key
+		if key.symbol != "" {
+			entries = append(entries, fmt.Sprintf("%s  %s", key.symbol, key.name))
+		}
+	}
+
+	for i, entry := range entries {
+		fmt.Fprint(w, entry)
+		switch {
+		case (i+1)%keysPerLine == 0 || i == len(entries)-1:
+			fmt.Fprintln(w)
+		case text.RuneWidthWithoutEscSequences(entry) < 16:
+			// two tab stops keep short entries aligned with long ones
+			fmt.Fprint(w, "\t\t")
+		default:
+			fmt.Fprint(w, "\t")
+		}
+	}
+	fmt.Fprintln(w)
 }
@@ -0,0 +1,94 @@
+package outputter
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+
+	"nodepp/internal/config"
+	"nodepp/internal/structs"
+)
+
+// Renderer writes a representation of the cluster data to the given stream
+type Renderer interface {
+	Render(cd *structs.ClusterData, w io.Writer) error
+}
+
+// Options holds the settings shared by all renderers
+type Options struct {
+	ShowUsage          bool
+	ShowPools          bool
+	ShowUpgradeDetails bool
+	ShowTopology       bool
+	ShowKeys           bool
+	HighlightChanges   bool
+	Thresholds         config.ThresholdConfig
+	SortBy             []structs.SortKey
+
+	// GroupBy shows rows in groups with a summary of each, if set
+	GroupBy string
+
+	// CustomColumns are extra table columns defined by JSONPath expressions
+	CustomColumns []CustomColumn
+
+	// Theme sets the symbols, colours and table style, defaulting to the emoji theme
+	Theme Theme
+	// NoColor disables coloured output
+	NoColor bool
+	// Stream writes each JSON document on a single line, so that repeated
+	// renders in watch mode form newline-delimited JSON
+	Stream bool
+}
+
+// sortKeys returns the keys to sort rows by, defaulting to their role
+func sortKeys(keys []structs.SortKey) []structs.SortKey {
+	if len(keys) == 0 {
+		return structs.DefaultSort
+	}
+	return keys
+}
+
+// theme returns the theme to render with, with its markers coloured, or with
+// no colours at all if they are disabled
+func (o Options) theme() Theme {
+	theme := o.Theme
+	if theme.Name == "" {
+		theme = themes[ThemeEmoji]
+	}
+	if o.NoColor {
+		return theme.uncolored()
+	}
+	return theme.colored()
+}
+
+// NewRenderer returns the renderer for the given output format
+func NewRenderer(format string, opts Options) (Renderer, error) {
+	switch format {
+	case FormatTable:
+		return NewTableRenderer(opts), nil
+	case FormatWide:
+		r := NewTableRenderer(opts)
+		r.Wide = true
+		return r, nil
+	case FormatJSON, FormatYAML:
+		return &DocumentRenderer{Format: format, SortBy: opts.SortBy, Compact: opts.Stream}, nil
+	}
+	if r, ok, err := NewTemplateRenderer(format, opts); ok {
+		return r, err
+	}
+	return nil, fmt.Errorf("unsupported output format %q", format)
+}
+
+// plainStyle draws tables as space-separated ASCII columns, like kubectl
+var plainStyle = table.Style{
+	Name:    "plain",
+	Box:     table.StyleBoxDefault,
+	Format:  table.FormatOptionsDefault,
+	Options: table.OptionsNoBordersAndSeparators,
+}
+
+// ClearScreen moves the cursor home and clears the terminal, for in-place refreshes
+func ClearScreen(w io.Writer) {
+	fmt.Fprint(w, "\033[H\033[2J")
+}
@@ -0,0 +1,156 @@
+package outputter
+
+import (
+	"fmt"
+	"strings"
+
+	"nodepp/internal/consts"
+)
+
+// Symbols are the markers used to flag node, machine and cluster states
+type Symbols struct {
+	Master         string
+	Infra          string
+	Worker         string
+	Missing        string
+	NotReady       string
+	Cordoned       string
+	Updating       string
+	Failed         string
+	Deleting       string
+	Provisioning   string
+	DiskPressure   string
+	MemoryPressure string
+	Hot            string
+	Version        string
+	Desired        string
+	Warning        string
+	Critical       string
+
+	// Changed marks rows whose state changed since the last refresh in watch
+	// mode, so they stand out without colour
+	Changed string
+
+	// Separator is placed between a value and its markers, and between
+	// several markers in the same cell
+	Separator string
+}
+
+// EmojiSymbols mark states with emoji
+var EmojiSymbols = Symbols{
+	Master:         string(consts.EMOJI_BUILDING),
+	Infra:          string(consts.EMOJI_BRICK),
+	Worker:         string(consts.EMOJI_WORKER),
+	Missing:        string(consts.EMOJI_QUESTION),
+	NotReady:       string(consts.EMOJI_SIREN),
+	Cordoned:       string(consts.EMOJI_ROADBLOCK),
+	Updating:       string(consts.EMOJI_WRENCH),
+	Failed:         string(consts.EMOJI_CROSS),
+	Deleting:       string(consts.EMOJI_WASTE),
+	Provisioning:   string(consts.EMOJI_UPARROW),
+	DiskPressure:   string(consts.EMOJI_DISK),
+	MemoryPressure: string(consts.EMOJI_EXPLODE),
+	Hot:            string(consts.EMOJI_FIRE),
+	Version:        string(consts.EMOJI_GEAR),
+	Desired:        string(consts.EMOJI_SOON),
+	Warning:        string(consts.EMOJI_WARN),
+	Critical:       string(consts.EMOJI_SIREN),
+	Changed:        string(consts.EMOJI_REFRESH),
+}
+
+// NerdFontSymbols mark states with Font Awesome glyphs, which need a patched
+// Nerd Font to display
+var NerdFontSymbols = Symbols{
+	Master:         "\uf233",
+	Infra:          "\uf1b3",
+	Worker:         "\uf085",
+	Missing:        "\uf059",
+	NotReady:       "\uf057",
+	Cordoned:       "\uf05e",
+	Updating:       "\uf0ad",
+	Failed:         "\uf00d",
+	Deleting:       "\uf1f8",
+	Provisioning:   "\uf0aa",
+	DiskPressure:   "\uf0a0",
+	MemoryPressure: "\uf2db",
+	Hot:            "\uf06d",
+	Version:        "\uf02b",
+	Desired:        "\uf178",
+	Warning:        "\uf071",
+	Critical:       "\uf06a",
+	Changed:        "\uf021",
+	Separator:      " ",
+}
+
+// PlainSymbols mark states with short text codes, for terminals and tools
+// that cannot display emoji
+var PlainSymbols = Symbols{
+	Missing:        "MISSING",
+	NotReady:       "NR",
+	Cordoned:       "CORD",
+	Updating:       "UPD",
+	Failed:         "FAIL",
+	Deleting:       "DEL",
+	Provisioning:   "PROV",
+	DiskPressure:   "DISK",
+	MemoryPressure: "MEM",
+	Hot:            "HOT",
+	Desired:        "->",
+	Warning:        "!",
+	Critical:       "!!",
+	Changed:        "*",
+	Separator:      " ",
+}
+
+// set overrides the named symbol
+func (s *Symbols) set(name string, value string) error {
+	fields := map[string]*string{
+		"master":         &s.Master,
+		"infra":          &s.Infra,
+		"worker":         &s.Worker,
+		"missing":        &s.Missing,
+		"notReady":       &s.NotReady,
+		"cordoned":       &s.Cordoned,
+		"updating":       &s.Updating,
+		"failed":         &s.Failed,
+		"deleting":       &s.Deleting,
+		"provisioning":   &s.Provisioning,
+		"diskPressure":   &s.DiskPressure,
+		"memoryPressure": &s.MemoryPressure,
+		"hot":            &s.Hot,
+		"version":        &s.Version,
+		"desired":        &s.Desired,
+		"warning":        &s.Warning,
+		"critical":       &s.Critical,
+		"changed":        &s.Changed,
+		"separator":      &s.Separator,
+	}
+	field, ok := fields[name]
+	if !ok {
+		return fmt.Errorf("unknown theme symbol %q", name)
+	}
+	*field = value
+	return nil
+}
+
+// mark appends the markers that are set to a value
+func (s Symbols) mark(value string, markers ...string) string {
+	set := make([]string, 0, len(markers))
+	if value != "" {
+		set = append(set, value)
+	}
+	for _, marker := range markers {
+		if marker != "" {
+			set = append(set, marker)
+		}
+	}
+	return strings.Join(set, s.Separator)
+}
+
+// label prefixes text with a marker, if the marker is set
+func label(marker string, text string) string {
+	if marker == "" {
+		return text
+	}
+	return marker + " " + text
+}
@@ -0,0 +1,123 @@
+package outputter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"nodepp/internal/config"
+	"nodepp/internal/structs"
+	"nodepp/internal/util"
+)
+
+// Go template output formats, given as go-template=TEMPLATE or
+// go-template-file=PATH
+const (
+	FormatGoTemplate     = "go-template"
+	FormatGoTemplateFile = "go-template-file"
+)
+
+// TemplateRenderer executes a Go template against the cluster data
+type TemplateRenderer struct {
+	Template *template.Template
+	SortBy   []structs.SortKey
+}
+
+// IsTemplateFormat reports whether format is a go-template or
+// go-template-file output format
+func IsTemplateFormat(format string) bool {
+	name, _, ok := strings.Cut(format, "=")
+	return ok && (name == FormatGoTemplate || name == FormatGoTemplateFile)
+}
+
+// NewTemplateRenderer parses a template given in a go-template or
+// go-template-file output format. It reports false if the format is neither.
+func NewTemplateRenderer(format string, opts Options) (*TemplateRenderer, bool, error) {
+	if !IsTemplateFormat(format) {
+		return nil, false, nil
+	}
+	name, arg, _ := strings.Cut(format, "=")
+	text := arg
+	if name == FormatGoTemplateFile {
+		data, err := os.ReadFile(arg)
+		if err != nil {
+			return nil, true, fmt.Errorf("reading template: %v", err)
+		}
+		text = string(data)
+	}
+	if text == "" {
+		return nil, true, fmt.Errorf("%s output needs a template", name)
+	}
+
+	tmpl, err := template.New(name).Funcs(templateFuncs(opts.theme().Symbols, opts.Thresholds)).Parse(text)
+	if err != nil {
+		return nil, true, fmt.Errorf("parsing template: %v", err)
+	}
+	return &TemplateRenderer{Template: tmpl, SortBy: opts.SortBy}, true, nil
+}
+
+func (r *TemplateRenderer) Render(cd *structs.ClusterData, w io.Writer) error {
+	cd.Sort(sortKeys(r.SortBy))
+	return r.Template.Execute(w, cd)
+}
+
+// templateFuncs are the helpers available to templates, giving the values
+// that the table derives from each row
+func templateFuncs(s Symbols, thresholds config.ThresholdConfig) template.FuncMap {
+	percent := func(r *structs.ResourceMetric, value func(*structs.ResourceMetric) float64) int64 {
+		if r == nil {
+			return 0
+		}
+		return int64(value(r))
+	}
+	return template.FuncMap{
+		// role is the leading role of a node, as shown in the ROLE column
+		"role": func(n *structs.NodeData) string {
+			return makeRoleValue(n.Roles, s)
+		},
+		// cpuPercent, memoryPercent and podsPercent are usage as a share of allocatable
+		"cpuPercent": func(n *structs.NodeData) int64 {
+			return percent(n.Cpu, (*structs.ResourceMetric).UtilizationPercent)
+		},
+		"memoryPercent": func(n *structs.NodeData) int64 {
+			return percent(n.Memory, (*structs.ResourceMetric).UtilizationPercent)
+		},
+		"podsPercent": func(n *structs.NodeData) int64 {
+			return percent(n.Pods, (*structs.ResourceMetric).UtilizationPercent)
+		},
+		// cpuRequestsPercent and memoryRequestsPercent are the sum of pod
+		// requests as a share of allocatable
+		"cpuRequestsPercent": func(n *structs.NodeData) int64 {
+			return percent(n.Cpu, (*structs.ResourceMetric).RequestsPercent)
+		},
+		"memoryRequestsPercent": func(n *structs.NodeData) int64 {
+			return percent(n.Memory, (*structs.ResourceMetric).RequestsPercent)
+		},
+		// statuses lists the states a row is in, as accepted by --only
+		"statuses": func(n *structs.NodeData) []string {
+			return structs.RowStatuses(n, thresholds)
+		},
+		// is reports whether a row is in the given state
+		"is": func(status string, n *structs.NodeData) (bool, error) {
+			filter, err := structs.NewStatusFilter([]string{status}, thresholds)
+			if err != nil {
+				return false, err
+			}
+			return filter.Matches(n), nil
+		},
+		// currentVersion is the version the cluster last completed updating to
+		"currentVersion": func(cd *structs.ClusterData) string {
+			if cd.Version == nil {
+				return ""
+			}
+			version, err := util.GetCurrentVersion(cd.Version)
+			if err != nil {
+				return ""
+			}
+			return version
+		},
+		"join": strings.Join,
+	}
+}
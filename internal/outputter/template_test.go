@@ -0,0 +1,58 @@
+package outputter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"nodepp/internal/structs"
+)
+
+func TestTemplateRenderer(t *testing.T) {
+	cd := &structs.ClusterData{Nodes: []*structs.NodeData{
+		{NodeName: "worker-1", Roles: []string{"worker"}, Ready: true, Cordoned: true},
+		{NodeName: "worker-0", Roles: []string{"worker"}, Ready: true, Cpu: &structs.ResourceMetric{
+			Allocatable: resource.MustParse("4"),
+			Utilization: resource.MustParse("3900m"),
+		}},
+	}}
+
+	tmpl := `{{range .Nodes}}{{.NodeName}} {{cpuPercent .}} {{join (statuses .) ","}} {{is "cordoned" .}}{{"\n"}}{{end}}`
+	r, err := NewRenderer("go-template="+tmpl, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := r.Render(cd, &out); err != nil {
+		t.Fatal(err)
+	}
+	expected := "worker-1 0 cordoned true\nworker-0 97 hot false\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+
+	path := filepath.Join(t.TempDir(), "nodes.tmpl")
+	if err := os.WriteFile(path, []byte(`{{len .Nodes}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r, err = NewRenderer("go-template-file="+path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := r.Render(cd, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "2" {
+		t.Errorf("expected %q, got %q", "2", out.String())
+	}
+
+	for _, format := range []string{"go-template=", "go-template={{.Nodes", "go-template-file=" + filepath.Join(t.TempDir(), "missing")} {
+		if _, err := NewRenderer(format, Options{}); err == nil {
+			t.Errorf("expected an error for %q", format)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package outputter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+
+	"nodepp/internal/config"
+)
+
+// Theme names
+const (
+	ThemeEmoji      = "emoji"
+	ThemeNerdFont   = "nerd-font"
+	ThemeASCII      = "ascii"
+	ThemeColourOnly = "colour-only"
+)
+
+// Colors are the colours used for each kind of output
+type Colors struct {
+	// Heading colours section headings
+	Heading text.Colors
+	// Text colours section contents
+	Text text.Colors
+	// Problem colours lines that report failures
+	Problem text.Colors
+	// Critical colours critical markers and table rows
+	Critical text.Colors
+	// Warning colours warning markers and table rows
+	Warning text.Colors
+	// Changed colours rows that changed since the last refresh
+	Changed text.Colors
+	// Footer colours the table footer
+	Footer text.Colors
+}
+
+// Theme is a named set of symbols, colours and table style
+type Theme struct {
+	Name    string
+	Symbols Symbols
+	Colors  Colors
+	Style   table.Style
+
+	// ColorSymbols colours markers by severity, for symbols that do not
+	// carry a colour of their own
+	ColorSymbols bool
+}
+
+var defaultColors = Colors{
+	Heading:  text.Colors{text.FgHiYellow},
+	Text:     text.Colors{text.FgYellow},
+	Problem:  text.Colors{text.FgHiRed},
+	Critical: text.Colors{text.FgHiRed},
+	Warning:  text.Colors{text.FgHiYellow},
+	Changed:  text.Colors{text.Bold, text.FgHiCyan},
+	Footer:   text.Colors{text.FgHiYellow, text.BgHiBlack},
+}
+
+// themes are the built-in themes, by name
+var themes = map[string]Theme{
+	ThemeEmoji: {
+		Name:    ThemeEmoji,
+		Symbols: EmojiSymbols,
+		Colors:  defaultColors,
+		Style:   table.StyleColoredDark,
+	},
+	ThemeNerdFont: {
+		Name:         ThemeNerdFont,
+		Symbols:      NerdFontSymbols,
+		Colors:       defaultColors,
+		Style:        table.StyleColoredDark,
+		ColorSymbols: true,
+	},
+	ThemeASCII: {
+		Name:    ThemeASCII,
+		Symbols: PlainSymbols,
+		Style:   plainStyle,
+	},
+	ThemeColourOnly: {
+		Name:         ThemeColourOnly,
+		Symbols:      PlainSymbols,
+		Colors:       defaultColors,
+		Style:        table.StyleColoredDark,
+		ColorSymbols: true,
+	},
+}
+
+// ThemeNames returns the names of the built-in themes
+func ThemeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewTheme returns the named built-in theme with the overrides from the
+// config file applied
+func NewTheme(name string, overrides config.ThemeConfig) (Theme, error) {
+	theme, ok := themes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q, expected one of %s", name, strings.Join(ThemeNames(), "|"))
+	}
+	for symbolName, value := range overrides.Symbols {
+		if err := theme.Symbols.set(symbolName, value); err != nil {
+			return Theme{}, err
+		}
+	}
+	for colorName, value := range overrides.Colors {
+		if err := theme.Colors.set(colorName, value); err != nil {
+			return Theme{}, err
+		}
+	}
+	return theme, nil
+}
+
+// colored returns the theme with its markers coloured by severity, if the
+// theme colours its symbols
+func (t Theme) colored() Theme {
+	if !t.ColorSymbols {
+		return t
+	}
+	s := &t.Symbols
+	for _, marker := range []*string{&s.NotReady, &s.Failed, &s.Critical} {
+		*marker = colorMarker(t.Colors.Critical, *marker)
+	}
+	for _, marker := range []*string{&s.Missing, &s.Cordoned, &s.Updating, &s.Deleting, &s.Provisioning,
+		&s.DiskPressure, &s.MemoryPressure, &s.Hot, &s.Warning} {
+		*marker = colorMarker(t.Colors.Warning, *marker)
+	}
+	return t
+}
+
+// uncolored returns the theme without any colours, so that a renderer can
+// drop them without affecting others
+func (t Theme) uncolored() Theme {
+	t.Colors = Colors{}
+	t.ColorSymbols = false
+	t.Style.Color = table.ColorOptions{}
+	return t
+}
+
+func colorMarker(colors text.Colors, marker string) string {
+	if marker == "" {
+		return ""
+	}
+	return colors.Sprint(marker)
+}
+
+// set overrides the named colour with a comma separated list of colour names
+func (c *Colors) set(name string, value string) error {
+	fields := map[string]*text.Colors{
+		"heading":  &c.Heading,
+		"text":     &c.Text,
+		"problem":  &c.Problem,
+		"critical": &c.Critical,
+		"warning":  &c.Warning,
+		"changed":  &c.Changed,
+		"footer":   &c.Footer,
+	}
+	field, ok := fields[name]
+	if !ok {
+		return fmt.Errorf("unknown theme colour %q", name)
+	}
+	colors, err := ParseColors(value)
+	if err != nil {
+		return err
+	}
+	*field = colors
+	return nil
+}
+
+var colorNames = map[string]text.Color{
+	"bold":        text.Bold,
+	"faint":       text.Faint,
+	"italic":      text.Italic,
+	"underline":   text.Underline,
+	"black":       text.FgBlack,
+	"red":         text.FgRed,
+	"green":       text.FgGreen,
+	"yellow":      text.FgYellow,
+	"blue":        text.FgBlue,
+	"magenta":     text.FgMagenta,
+	"cyan":        text.FgCyan,
+	"white":       text.FgWhite,
+	"hi-black":    text.FgHiBlack,
+	"hi-red":      text.FgHiRed,
+	"hi-green":    text.FgHiGreen,
+	"hi-yellow":   text.FgHiYellow,
+	"hi-blue":     text.FgHiBlue,
+	"hi-magenta":  text.FgHiMagenta,
+	"hi-cyan":     text.FgHiCyan,
+	"hi-white":    text.FgHiWhite,
+	"bg-black":    text.BgBlack,
+	"bg-hi-black": text.BgHiBlack,
+}
+
+// ParseColors parses a comma separated list of colour names, such as
+// "bold,hi-cyan". An empty list means no colour.
+func ParseColors(value string) (text.Colors, error) {
+	colors := text.Colors{}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || name == "none" {
+			continue
+		}
+		color, ok := colorNames[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown colour %q", name)
+		}
+		colors = append(colors, color)
+	}
+	return colors, nil
+}
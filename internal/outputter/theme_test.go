@@ -0,0 +1,117 @@
+package outputter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+
+	"nodepp/internal/config"
+	"nodepp/internal/structs"
+)
+
+func TestNewThemeOverrides(t *testing.T) {
+	theme, err := NewTheme(ThemeEmoji, config.ThemeConfig{
+		Symbols: map[string]string{"worker": "W", "deleting": "DEL"},
+		Colors:  map[string]string{"heading": "bold, hi-blue"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if theme.Symbols.Worker != "W" || theme.Symbols.Deleting != "DEL" {
+		t.Errorf("symbols were not overridden: %+v", theme.Symbols)
+	}
+	if theme.Symbols.Master != EmojiSymbols.Master {
+		t.Errorf("symbols that were not overridden should be kept")
+	}
+	if len(theme.Colors.Heading) != 2 || theme.Colors.Heading[1] != text.FgHiBlue {
+		t.Errorf("heading colour was not overridden: %v", theme.Colors.Heading)
+	}
+	if themes[ThemeEmoji].Symbols.Worker == "W" {
+		t.Errorf("overrides must not change the built-in theme")
+	}
+}
+
+func TestNewThemeRejectsUnknownNames(t *testing.T) {
+	for _, overrides := range []config.ThemeConfig{
+		{Symbols: map[string]string{"cow": "W"}},
+		{Colors: map[string]string{"heading": "plaid"}},
+		{Colors: map[string]string{"sidebar": "red"}},
+	} {
+		if _, err := NewTheme(ThemeEmoji, overrides); err == nil {
+			t.Errorf("expected an error for %+v", overrides)
+		}
+	}
+	if _, err := NewTheme("sepia", config.ThemeConfig{}); err == nil {
+		t.Errorf("expected an error for an unknown theme")
+	}
+}
+
+func TestKeysFollowTheme(t *testing.T) {
+	for _, name := range ThemeNames() {
+		theme := themes[name]
+		out := new(bytes.Buffer)
+		printKeys(out, theme.Symbols, false)
+		for _, symbol := range []string{theme.Symbols.NotReady, theme.Symbols.Cordoned, theme.Symbols.Hot} {
+			if !strings.Contains(out.String(), symbol+"  ") {
+				t.Errorf("%s: keys do not show %q:\n%s", name, symbol, out.String())
+			}
+		}
+	}
+}
+
+func TestNoColorIsPerRenderer(t *testing.T) {
+	cd := &structs.ClusterData{Nodes: []*structs.NodeData{healthyNode("worker-0", "worker")}}
+	render := func(opts Options) string {
+		r := NewTableRenderer(opts)
+		r.now = func() time.Time { return goldenNow }
+		out := new(bytes.Buffer)
+		if err := r.Render(cd, out); err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+
+	if out := render(Options{Theme: themes[ThemeColourOnly], NoColor: true}); strings.Contains(out, "\033[") {
+		t.Errorf("expected no escape codes without colour, got %q", out)
+	}
+	if out := render(Options{Theme: themes[ThemeColourOnly]}); !strings.Contains(out, "\033[") {
+		t.Errorf("expected other renderers to keep their colours, got %q", out)
+	}
+}
+
+func TestChangedRowsMarkedWithoutColor(t *testing.T) {
+	for _, name := range []string{ThemeEmoji, ThemeASCII} {
+		r := NewTableRenderer(Options{Theme: themes[name], NoColor: true, HighlightChanges: true})
+		r.now = func() time.Time { return goldenNow }
+		marker := r.Theme.Symbols.Changed
+
+		cd := &structs.ClusterData{Nodes: []*structs.NodeData{healthyNode("worker-0", "worker"), healthyNode("worker-1", "worker")}}
+		if err := r.Render(cd, new(bytes.Buffer)); err != nil {
+			t.Fatal(err)
+		}
+		cd.Nodes[1].Cordoned = true
+		out := new(bytes.Buffer)
+		if err := r.Render(cd, out); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, line := range strings.Split(out.String(), "\n") {
+			switch {
+			case strings.Contains(line, "worker-1 "):
+				if !strings.Contains(line, marker+" ") {
+					t.Errorf("%s: expected the changed row to be marked with %q: %q", name, marker, line)
+				}
+			case strings.Contains(line, "worker-0 "):
+				if strings.Contains(line, marker+" ") {
+					t.Errorf("%s: unchanged row is marked: %q", name, line)
+				}
+			}
+		}
+		if strings.Contains(out.String(), "\033[") {
+			t.Errorf("%s: expected no escape codes without colour", name)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package outputter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	v1 "github.com/openshift/api/config/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"nodepp/internal/structs"
+	"nodepp/internal/util"
+)
+
+// upgradeStuckAfter is how long an update may progress before it is reported as stuck
+const upgradeStuckAfter = 2 * time.Hour
+
+// showUpgradeDetails prints a panel describing the health of the cluster's
+// updates, built from the ClusterVersion status
+func showUpgradeDetails(w io.Writer, cd *structs.ClusterData, t Theme, now time.Time) {
+	s := t.Symbols
+	cv := cd.Version
+	if cv == nil {
+		return
+	}
+
+	fmt.Fprintln(w, t.Colors.Heading.Sprintf(" Cluster Version:"))
+
+	// Versions and channel
+	current, err := util.GetCurrentVersion(cv)
+	if err != nil {
+		current = "unknown"
+	}
+	summary := fmt.Sprintf(" Current: %s", current)
+	if cv.Status.Desired.Version != "" && cv.Status.Desired.Version != current {
+		summary += fmt.Sprintf("  Desired: %s", cv.Status.Desired.Version)
+	}
+	if cv.Spec.Channel != "" {
+		summary += fmt.Sprintf("  Channel: %s", cv.Spec.Channel)
+	}
+	fmt.Fprintln(w, t.Colors.Text.Sprint(summary))
+
+	// Conditions
+	for _, conditionType := range []v1.ClusterStatusConditionType{v1.OperatorAvailable, v1.OperatorProgressing, util.ClusterVersionFailing} {
+		cnd := util.GetCondition(cv, conditionType)
+		if cnd == nil {
+			continue
+		}
+		line := fmt.Sprintf(" %s: %s", cnd.Type, cnd.Status)
+		if !cnd.LastTransitionTime.IsZero() {
+			line += fmt.Sprintf(" (%s)", duration.HumanDuration(now.Sub(cnd.LastTransitionTime.Time)))
+		}
+		if cnd.Message != "" {
+			line += "  " + cnd.Message
+		}
+		fmt.Fprintln(w, t.Colors.Text.Sprint(line))
+	}
+
+	// Explicit problems
+	if failing := util.GetCondition(cv, util.ClusterVersionFailing); failing != nil && failing.Status == v1.ConditionTrue {
+		fmt.Fprintln(w, t.Colors.Problem.Sprintf(" %s: %s", label(s.Critical, "Update is failing"), failing.Message))
+	}
+	if inProgress := util.GetUpdateInProgress(cv); inProgress != nil && util.IsUpdateStuck(cv, now, upgradeStuckAfter) {
+		fmt.Fprintln(w, t.Colors.Problem.Sprintf(" %s to %s has been progressing for %s and may be stuck",
+			label(s.Warning, "Update"), inProgress.Version, duration.HumanDuration(now.Sub(inProgress.StartedTime.Time))))
+	}
+	for _, partial := range util.GetPartialUpdates(cv) {
+		fmt.Fprintln(w, t.Colors.Problem.Sprintf(" %s to %s was only partially applied", label(s.Warning, "Update"), partial.Version))
+	}
+
+	// Update history
+	if len(cv.Status.History) > 0 {
+		fmt.Fprintln(w, t.Colors.Heading.Sprintf(" History:"))
+		for _, history := range cv.Status.History {
+			line := fmt.Sprintf("   %-12s %-10s", history.Version, history.State)
+			switch {
+			case history.CompletionTime != nil:
+				line += fmt.Sprintf(" took %s", duration.HumanDuration(history.CompletionTime.Sub(history.StartedTime.Time)))
+			case !history.StartedTime.IsZero():
+				line += fmt.Sprintf(" in progress for %s", duration.HumanDuration(now.Sub(history.StartedTime.Time)))
+			}
+			if !history.Verified {
+				line += " (unverified)"
+			}
+			fmt.Fprintln(w, t.Colors.Text.Sprint(line))
+		}
+	}
+
+	// Updates
+	if len(cv.Status.AvailableUpdates) > 0 {
+		versions := make([]string, 0, len(cv.Status.AvailableUpdates))
+		for _, release := range cv.Status.AvailableUpdates {
+			versions = append(versions, release.Version)
+		}
+		fmt.Fprintln(w, t.Colors.Heading.Sprintf(" Available Updates: ")+t.Colors.Text.Sprint(strings.Join(versions, ", ")))
+	}
+	if len(cv.Status.ConditionalUpdates) > 0 {
+		fmt.Fprintln(w, t.Colors.Heading.Sprintf(" Conditional Updates:"))
+		for _, update := range cv.Status.ConditionalUpdates {
+			fmt.Fprintln(w, t.Colors.Text.Sprintf("   %s", update.Release.Version))
+			for _, risk := range update.Risks {
+				fmt.Fprintln(w, t.Colors.Text.Sprintf("     %s: %s %s", label(s.Warning, risk.Name), risk.Message, risk.URL))
+			}
+		}
+	}
+	fmt.Fprintln(w)
+}
@@ -2,13 +2,91 @@ package structs
 
 import (
 	v1 "github.com/openshift/api/config/v1"
-	"sort"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	resourcehelper "k8s.io/kubectl/pkg/util/resource"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	"nodepp/internal/consts"
+)
+
+// Data sources that nodepp can render without
+const (
+	SourceMachines           = "machines"
+	SourceMachineSets        = "machine sets"
+	SourceNodeMetrics        = "node metrics"
+	SourcePods               = "pods"
+	SourceMachineConfigPools = "machine config pools"
+	SourceClusterVersion     = "cluster version"
+	SourceClusterOperators   = "cluster operators"
 )
 
 type ClusterData struct {
 	Nodes            []*NodeData
 	Version          *v1.ClusterVersion
 	ClusterOperators *v1.ClusterOperatorList
+	Pools            []*PoolData
+	MachineSets      []*MachineSetData
+	Warnings         []Warning
+}
+
+// Warning records an optional data source that could not be retrieved
+type Warning struct {
+	Source  string `json:"source"`
+	Message string `json:"message"`
+	// NotServed is set when the cluster does not serve the source's API at
+	// all, such as the Machine API on HyperShift hosted clusters, rather than
+	// serving it and failing
+	NotServed bool `json:"notServed,omitempty"`
+}
+
+// NewWarning returns a warning that the given data source could not be
+// retrieved because of err
+func NewWarning(source string, err error) Warning {
+	return Warning{
+		Source:    source,
+		Message:   err.Error(),
+		NotServed: apierrors.IsNotFound(err) || meta.IsNoMatchError(err),
+	}
+}
+
+// AddWarning records that the given data source is unavailable
+func (c *ClusterData) AddWarning(source string, err error) {
+	c.Warnings = append(c.Warnings, NewWarning(source, err))
+}
+
+// Unavailable reports whether the given data source could not be retrieved
+func (c *ClusterData) Unavailable(source string) bool {
+	for _, w := range c.Warnings {
+		if w.Source == source {
+			return true
+		}
+	}
+	return false
+}
+
+// OperatorStatuses returns the status of every cluster operator
+func (c *ClusterData) OperatorStatuses() []*OperatorStatus {
+	statuses := make([]*OperatorStatus, 0)
+	if c.ClusterOperators == nil {
+		return statuses
+	}
+	for i := range c.ClusterOperators.Items {
+		statuses = append(statuses, NewFromClusterOperator(&c.ClusterOperators.Items[i]))
+	}
+	return statuses
+}
+
+// UnhealthyOperators returns the cluster operators that are down or degraded
+func (c *ClusterData) UnhealthyOperators() []*OperatorStatus {
+	unhealthy := make([]*OperatorStatus, 0)
+	for _, status := range c.OperatorStatuses() {
+		if status.Down || status.Degraded {
+			unhealthy = append(unhealthy, status)
+		}
+	}
+	return unhealthy
 }
 
 // GetNode returns a node with the given node name or machine name
@@ -22,17 +100,167 @@ func (c *ClusterData) GetNode(name string) *NodeData {
 	return nil
 }
 
-// SortByRole sorts the cluster's nodes by their leading role
-func (c *ClusterData) SortByRole() {
-	sort.Slice(c.Nodes, func(i, j int) bool {
-		if len(c.Nodes[i].Roles) == 0 {
-			return false
+// AddMachine merges machine data into the cluster data. Machines without a
+// node are added as rows of their own.
+func (c *ClusterData) AddMachine(m *NodeData) {
+	if m.NodeName == "" {
+		c.Nodes = append(c.Nodes, m)
+		return
+	}
+	// just merge in machine info, if we pulled node info originally
+	node := c.GetNode(m.NodeName)
+	if node != nil {
+		node.Machine = m.Machine
+		node.MachinePhase = m.MachinePhase
+		node.MachineError = m.MachineError
+		node.InstanceState = m.InstanceState
+		node.ProviderID = m.ProviderID
+		node.MachineConditions = m.MachineConditions
+		node.MachineSet = m.MachineSet
+		// the node's own topology labels take precedence over the machine's
+		if node.Zone == "" {
+			node.Zone = m.Zone
 		}
-		if len(c.Nodes[j].Roles) == 0 {
-			return true
+		if node.Region == "" {
+			node.Region = m.Region
+		}
+		if node.InstanceType == "" {
+			node.InstanceType = m.InstanceType
+		}
+	}
+}
+
+// AddNodeMetrics merges metrics-server usage into the matching node
+func (c *ClusterData) AddNodeMetrics(nm *metricsv1beta1.NodeMetrics) {
+	// ignore nodes we never pulled info for originally
+	node := c.GetNode(nm.Name)
+	if node == nil {
+		return
+	}
+	if node.Cpu != nil {
+		node.Cpu.Utilization = nm.Usage.Cpu().DeepCopy()
+	}
+	if node.Memory != nil {
+		node.Memory.Utilization = nm.Usage.Memory().DeepCopy()
+	}
+}
+
+// AddPods counts the pods scheduled to each node that still count towards its
+// pod capacity, i.e. those that have not terminated, and sums their CPU and
+// memory requests and limits in the same way as `oc describe node`
+func (c *ClusterData) AddPods(pods []corev1.Pod) {
+	usage := make(map[string]*PodUsage)
+	for i := range pods {
+		pod := &pods[i]
+		nodeName := pod.Spec.NodeName
+		if nodeName == "" || PodTerminated(pod) {
+			continue
+		}
+		if usage[nodeName] == nil {
+			usage[nodeName] = new(PodUsage)
+		}
+		usage[nodeName].Add(NewPodUsage(pod))
+	}
+	c.AddPodUsage(usage)
+}
+
+// AddPodUsage sets the pod count, requests and limits of each node from the
+// usage of the pods scheduled to it, keyed by node name
+func (c *ClusterData) AddPodUsage(usage map[string]*PodUsage) {
+	for _, node := range c.Nodes {
+		if node.NodeName == "" {
+			continue
+		}
+		nodeUsage := usage[node.NodeName]
+		if nodeUsage == nil {
+			nodeUsage = new(PodUsage)
+		}
+		if node.Pods != nil {
+			node.Pods.Utilization = *resource.NewQuantity(nodeUsage.Count, resource.DecimalSI)
+		}
+		if node.Cpu != nil {
+			node.Cpu.Requests = nodeUsage.Requests.Cpu().DeepCopy()
+			node.Cpu.Limits = nodeUsage.Limits.Cpu().DeepCopy()
 		}
-		return roleSortOrder(c.Nodes[i].Roles[0]) < roleSortOrder(c.Nodes[j].Roles[0])
-	})
+		if node.Memory != nil {
+			node.Memory.Requests = nodeUsage.Requests.Memory().DeepCopy()
+			node.Memory.Limits = nodeUsage.Limits.Memory().DeepCopy()
+		}
+	}
+}
+
+// PodUsage counts pods that count towards a node's pod capacity, and sums
+// their CPU and memory requests and limits
+type PodUsage struct {
+	Count    int64
+	Requests corev1.ResourceList
+	Limits   corev1.ResourceList
+}
+
+// NewPodUsage returns the usage of a single pod
+func NewPodUsage(pod *corev1.Pod) *PodUsage {
+	requests, limits := resourcehelper.PodRequestsAndLimits(pod)
+	return &PodUsage{Count: 1, Requests: requests, Limits: limits}
+}
+
+// Add adds the pods, requests and limits of another usage to this one
+func (u *PodUsage) Add(o *PodUsage) {
+	u.Count += o.Count
+	u.Requests = addResourceList(u.Requests, o.Requests)
+	u.Limits = addResourceList(u.Limits, o.Limits)
+}
+
+// DeepCopy returns a copy of the usage
+func (u *PodUsage) DeepCopy() *PodUsage {
+	c := new(PodUsage)
+	c.Add(u)
+	return c
+}
+
+// addResourceList adds the quantities in add to list, allocating list if needed
+func addResourceList(list corev1.ResourceList, add corev1.ResourceList) corev1.ResourceList {
+	if list == nil {
+		list = corev1.ResourceList{}
+	}
+	for name, quantity := range add {
+		if value, ok := list[name]; ok {
+			value.Add(quantity)
+			list[name] = value
+		} else {
+			list[name] = quantity.DeepCopy()
+		}
+	}
+	return list
+}
+
+// PodTerminated reports whether a pod has finished and released its resources
+func PodTerminated(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
+
+// AddPools records the MachineConfigPools and assigns each node to its pool.
+// Nodes in a custom pool also match the worker pool's selector, so the worker
+// pool is only used when no other pool matches, as the MCO does.
+func (c *ClusterData) AddPools(pools []*PoolData) {
+	c.Pools = pools
+	for _, node := range c.Nodes {
+		if node.NodeName == "" {
+			continue
+		}
+		for _, pool := range pools {
+			if !pool.Matches(node.Labels) {
+				continue
+			}
+			if node.Pool == "" || node.Pool == consts.WorkerPoolName {
+				node.Pool = pool.Name
+			}
+		}
+	}
+}
+
+// SortByRole sorts the cluster's nodes by their leading role
+func (c *ClusterData) SortByRole() {
+	c.Sort(DefaultSort)
 }
 
 // roleSortOrder decides the order for sorting roles
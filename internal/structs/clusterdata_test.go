@@ -1,6 +1,11 @@
 package structs
 
-import "testing"
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
 
 type sortByRoleTest struct {
 	arg               ClusterData
@@ -74,3 +79,49 @@ func TestSortByRole(t *testing.T) {
 		}
 	}
 }
+
+func testPod(nodeName string, phase v1.PodPhase, requests v1.ResourceList, limits v1.ResourceList) v1.Pod {
+	return v1.Pod{
+		Spec: v1.PodSpec{
+			NodeName: nodeName,
+			Containers: []v1.Container{
+				{Resources: v1.ResourceRequirements{Requests: requests, Limits: limits}},
+			},
+		},
+		Status: v1.PodStatus{Phase: phase},
+	}
+}
+
+func TestAddPods(t *testing.T) {
+	cd := ClusterData{
+		Nodes: []*NodeData{
+			{
+				NodeName: "worker-0",
+				Cpu:      &ResourceMetric{Allocatable: resource.MustParse("4")},
+				Memory:   &ResourceMetric{Allocatable: resource.MustParse("16Gi")},
+				Pods:     &ResourceMetric{Allocatable: resource.MustParse("250")},
+			},
+			{MachineName: "worker-1-machine"},
+		},
+	}
+	small := v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m"), v1.ResourceMemory: resource.MustParse("1Gi")}
+	large := v1.ResourceList{v1.ResourceCPU: resource.MustParse("2"), v1.ResourceMemory: resource.MustParse("4Gi")}
+	cd.AddPods([]v1.Pod{
+		testPod("worker-0", v1.PodRunning, small, large),
+		testPod("worker-0", v1.PodPending, small, nil),
+		testPod("worker-0", v1.PodSucceeded, large, large),
+		testPod("worker-1", v1.PodRunning, large, large),
+		testPod("", v1.PodPending, large, large),
+	})
+
+	node := cd.GetNode("worker-0")
+	if node.Pods.Utilization.Value() != 2 {
+		t.Errorf("expected 2 pods, got %v", node.Pods.Utilization.String())
+	}
+	if node.Cpu.Requests.MilliValue() != 1000 || node.Cpu.Limits.MilliValue() != 2000 {
+		t.Errorf("unexpected cpu requests/limits %v/%v", node.Cpu.Requests.String(), node.Cpu.Limits.String())
+	}
+	if node.Memory.RequestsPercent() != 12.5 || node.Memory.LimitsPercent() != 25 {
+		t.Errorf("unexpected memory requests/limits %v%%/%v%%", node.Memory.RequestsPercent(), node.Memory.LimitsPercent())
+	}
+}
@@ -0,0 +1,103 @@
+package structs
+
+import (
+	"fmt"
+	"strings"
+
+	"nodepp/internal/config"
+	"nodepp/internal/consts"
+)
+
+// Statuses that rows can be filtered by
+const (
+	StatusNotReady = "notready"
+	StatusCordoned = "cordoned"
+	StatusUpdating = "updating"
+	StatusFailed   = "failed"
+	StatusMissing  = "missing"
+	StatusHot      = "hot"
+	StatusPressure = "pressure"
+)
+
+// Statuses lists every status that rows can be filtered by
+var Statuses = []string{StatusNotReady, StatusCordoned, StatusUpdating, StatusFailed, StatusMissing, StatusHot, StatusPressure}
+
+// StatusFilter selects the rows that are in any of a set of states
+type StatusFilter struct {
+	statuses   []string
+	thresholds config.ThresholdConfig
+}
+
+// NewStatusFilter returns a filter for the given statuses. Nodes are hot when
+// a resource is above the thresholds for their role.
+func NewStatusFilter(statuses []string, thresholds config.ThresholdConfig) (*StatusFilter, error) {
+	for _, status := range statuses {
+		if !isStatus(status) {
+			return nil, fmt.Errorf("unknown status %q, expected one of %s", status, strings.Join(Statuses, "|"))
+		}
+	}
+	return &StatusFilter{statuses: statuses, thresholds: thresholds}, nil
+}
+
+func isStatus(status string) bool {
+	for _, s := range Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches reports whether a row is in any of the filter's states
+func (f *StatusFilter) Matches(n *NodeData) bool {
+	for _, status := range f.statuses {
+		if f.hasStatus(n, status) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *StatusFilter) hasStatus(n *NodeData, status string) bool {
+	switch status {
+	case StatusNotReady:
+		return n.NodeName != "" && !n.Ready
+	case StatusCordoned:
+		return n.Cordoned
+	case StatusUpdating:
+		return n.Updating || n.MCDState == consts.MachineConfigStateWorking
+	case StatusFailed:
+		return n.MachinePhase == "Failed"
+	case StatusMissing:
+		return n.Missing
+	case StatusHot:
+		return n.Hot(f.thresholds.For(n.Roles))
+	case StatusPressure:
+		return n.MemoryPressure || n.DiskPressure
+	}
+	return false
+}
+
+// RowStatuses returns every status a row is in, in the order of Statuses.
+// Nodes are hot when a resource is above the thresholds for their role.
+func RowStatuses(n *NodeData, thresholds config.ThresholdConfig) []string {
+	f := &StatusFilter{thresholds: thresholds}
+	statuses := make([]string, 0)
+	for _, status := range Statuses {
+		if f.hasStatus(n, status) {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// Filter removes the rows that do not match
+func (c *ClusterData) Filter(matches func(n *NodeData) bool) {
+	kept := make([]*NodeData, 0, len(c.Nodes))
+	for _, n := range c.Nodes {
+		if matches(n) {
+			kept = append(kept, n)
+		}
+	}
+	c.Nodes = kept
+}
@@ -0,0 +1,66 @@
+package structs
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"nodepp/internal/config"
+)
+
+func TestStatusFilter(t *testing.T) {
+	cd := &ClusterData{Nodes: []*NodeData{
+		{NodeName: "healthy", Ready: true},
+		{NodeName: "notready"},
+		{NodeName: "cordoned", Ready: true, Cordoned: true},
+		{NodeName: "pressure", Ready: true, DiskPressure: true},
+		{NodeName: "hot", Ready: true, Roles: []string{"master"}, Cpu: &ResourceMetric{
+			Allocatable: resource.MustParse("4"),
+			Utilization: resource.MustParse("3"),
+		}},
+		{MachineName: "provisioning-machine", MachinePhase: "Provisioning", Missing: true},
+		{MachineName: "failed-machine", MachinePhase: "Failed", Missing: true},
+	}}
+
+	tests := []struct {
+		statuses []string
+		expected []string
+	}{
+		{[]string{StatusNotReady}, []string{"notready"}},
+		{[]string{StatusCordoned, StatusPressure}, []string{"cordoned", "pressure"}},
+		{[]string{StatusMissing}, []string{"provisioning-machine", "failed-machine"}},
+		{[]string{StatusFailed}, []string{"failed-machine"}},
+		{[]string{StatusHot}, []string{"hot"}},
+	}
+	thresholds := config.ThresholdConfig{Roles: map[string]config.Thresholds{"master": {CPU: 70}}}
+	for _, test := range tests {
+		filter, err := NewStatusFilter(test.statuses, thresholds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		filtered := &ClusterData{Nodes: cd.Nodes}
+		filtered.Filter(filter.Matches)
+
+		var names []string
+		for _, n := range filtered.Nodes {
+			name := n.NodeName
+			if name == "" {
+				name = n.MachineName
+			}
+			names = append(names, name)
+		}
+		if len(names) != len(test.expected) {
+			t.Errorf("%v: expected %v, got %v", test.statuses, test.expected, names)
+			continue
+		}
+		for i := range names {
+			if names[i] != test.expected[i] {
+				t.Errorf("%v: expected %v, got %v", test.statuses, test.expected, names)
+			}
+		}
+	}
+
+	if _, err := NewStatusFilter([]string{"sleepy"}, thresholds); err == nil {
+		t.Errorf("expected an error for an unknown status")
+	}
+}
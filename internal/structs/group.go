@@ -0,0 +1,130 @@
+package structs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Fields that rows can be grouped by
+const (
+	GroupByMachineSet = "machineset"
+	GroupByZone       = "zone"
+)
+
+// GroupFields lists every field that rows can be grouped by
+var GroupFields = []string{GroupByMachineSet, GroupByZone}
+
+// ValidateGroupBy checks that rows can be grouped by the given field
+func ValidateGroupBy(field string) error {
+	for _, f := range GroupFields {
+		if f == field {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown group field %q, expected one of %s", field, strings.Join(GroupFields, "|"))
+}
+
+// RowGroup is a set of rows shown together beneath a summary
+type RowGroup struct {
+	// Name is empty for the group of rows that have no value for the field
+	Name  string
+	Nodes []*NodeData
+
+	// MachineSet is the MachineSet of the group when grouping by MachineSet,
+	// if it could be retrieved
+	MachineSet *MachineSetData
+
+	// ControlPlane is set for the group of control-plane rows when grouping
+	// by MachineSet, as no MachineSet owns them
+	ControlPlane bool
+}
+
+// GroupByMachineSet groups rows by the MachineSet that owns their machine.
+// Every MachineSet has a group, even when scaled down. Control-plane rows
+// follow in a group of their own, and other rows without a MachineSet come
+// last. The order of rows within a group is kept.
+func (c *ClusterData) GroupByMachineSet() []*RowGroup {
+	groups := make([]*RowGroup, 0, len(c.MachineSets)+1)
+	byName := make(map[string]*RowGroup)
+	for _, ms := range c.MachineSets {
+		group := &RowGroup{Name: ms.Name, MachineSet: ms}
+		groups = append(groups, group)
+		byName[ms.Name] = group
+	}
+
+	controlPlane := &RowGroup{ControlPlane: true}
+	orphans := &RowGroup{}
+	for _, n := range c.Nodes {
+		if n.MachineSet == "" {
+			if n.ControlPlane() {
+				controlPlane.Nodes = append(controlPlane.Nodes, n)
+			} else {
+				orphans.Nodes = append(orphans.Nodes, n)
+			}
+			continue
+		}
+		group, ok := byName[n.MachineSet]
+		if !ok {
+			// the MachineSet list is unavailable, or changed since the
+			// machines were listed
+			group = &RowGroup{Name: n.MachineSet}
+			groups = append(groups, group)
+			byName[n.MachineSet] = group
+		}
+		group.Nodes = append(group.Nodes, n)
+	}
+
+	if len(controlPlane.Nodes) > 0 {
+		groups = append(groups, controlPlane)
+	}
+	if len(orphans.Nodes) > 0 {
+		groups = append(groups, orphans)
+	}
+	return groups
+}
+
+// GroupByZone groups rows by their availability zone, in zone order, with
+// rows in no zone last. The order of rows within a group is kept.
+func (c *ClusterData) GroupByZone() []*RowGroup {
+	groups := make([]*RowGroup, 0)
+	byName := make(map[string]*RowGroup)
+	for _, n := range c.Nodes {
+		group, ok := byName[n.Zone]
+		if !ok {
+			group = &RowGroup{Name: n.Zone}
+			groups = append(groups, group)
+			byName[n.Zone] = group
+		}
+		group.Nodes = append(group.Nodes, n)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Name == "" || groups[j].Name == "" {
+			return groups[j].Name == "" && groups[i].Name != ""
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
+
+// NodeCount returns the number of rows in the group that have a node
+func (g *RowGroup) NodeCount() int {
+	count := 0
+	for _, n := range g.Nodes {
+		if n.NodeName != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// ReadyCount returns the number of nodes in the group that are ready
+func (g *RowGroup) ReadyCount() int {
+	count := 0
+	for _, n := range g.Nodes {
+		if n.NodeName != "" && n.Ready {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,92 @@
+package structs
+
+import (
+	"testing"
+)
+
+func TestGroupByMachineSet(t *testing.T) {
+	cd := &ClusterData{Nodes: []*NodeData{
+		{NodeName: "master-0", Roles: []string{"master"}},
+		{NodeName: "worker-manual-0"},
+		{NodeName: "worker-a-0", MachineSet: "worker-a"},
+		{NodeName: "worker-c-0", MachineSet: "worker-c"},
+		{NodeName: "worker-a-1", MachineSet: "worker-a"},
+	}}
+	cd.AddMachineSets([]*MachineSetData{{Name: "worker-b"}, {Name: "worker-a", Desired: 2}})
+
+	expected := []struct {
+		name         string
+		nodes        []string
+		machineSet   bool
+		controlPlane bool
+	}{
+		{"worker-a", []string{"worker-a-0", "worker-a-1"}, true, false},
+		{"worker-b", nil, true, false},
+		{"worker-c", []string{"worker-c-0"}, false, false},
+		{"", []string{"master-0"}, false, true},
+		{"", []string{"worker-manual-0"}, false, false},
+	}
+
+	groups := cd.GroupByMachineSet()
+	if len(groups) != len(expected) {
+		t.Fatalf("expected %d groups, got %d", len(expected), len(groups))
+	}
+	for i, group := range groups {
+		if group.Name != expected[i].name || (group.MachineSet != nil) != expected[i].machineSet || group.ControlPlane != expected[i].controlPlane {
+			t.Errorf("group %d: expected %q, got %q", i, expected[i].name, group.Name)
+		}
+		var names []string
+		for _, n := range group.Nodes {
+			names = append(names, n.NodeName)
+		}
+		if len(names) != len(expected[i].nodes) {
+			t.Errorf("group %q: expected %v, got %v", group.Name, expected[i].nodes, names)
+			continue
+		}
+		for j := range names {
+			if names[j] != expected[i].nodes[j] {
+				t.Errorf("group %q: expected %v, got %v", group.Name, expected[i].nodes, names)
+			}
+		}
+	}
+
+	if err := ValidateGroupBy(GroupByZone); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidateGroupBy("rack"); err == nil {
+		t.Errorf("expected an error for an unknown group field")
+	}
+}
+
+func TestGroupByZone(t *testing.T) {
+	cd := &ClusterData{Nodes: []*NodeData{
+		{NodeName: "b-0", Zone: "zone-b", Ready: true},
+		{NodeName: "none-0"},
+		{NodeName: "a-0", Zone: "zone-a", Ready: true},
+		{MachineName: "b-1-machine", Zone: "zone-b"},
+		{NodeName: "b-2", Zone: "zone-b"},
+	}}
+
+	expected := []struct {
+		name  string
+		rows  int
+		nodes int
+		ready int
+	}{
+		{"zone-a", 1, 1, 1},
+		{"zone-b", 3, 2, 1},
+		{"", 1, 1, 0},
+	}
+
+	groups := cd.GroupByZone()
+	if len(groups) != len(expected) {
+		t.Fatalf("expected %d groups, got %d", len(expected), len(groups))
+	}
+	for i, group := range groups {
+		e := expected[i]
+		if group.Name != e.name || len(group.Nodes) != e.rows || group.NodeCount() != e.nodes || group.ReadyCount() != e.ready {
+			t.Errorf("group %d: expected %q with %d rows, %d nodes and %d ready, got %q with %d rows, %d nodes and %d ready",
+				i, e.name, e.rows, e.nodes, e.ready, group.Name, len(group.Nodes), group.NodeCount(), group.ReadyCount())
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package structs
+
+import (
+	"sort"
+
+	"github.com/openshift/api/machine/v1beta1"
+)
+
+// MachineSetData summarises the replicas of a MachineSet
+type MachineSetData struct {
+	Name         string `json:"name"`
+	Desired      int32  `json:"desired"`
+	Current      int32  `json:"current"`
+	Ready        int32  `json:"ready"`
+	Available    int32  `json:"available"`
+	ErrorReason  string `json:"errorReason,omitempty"`
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+func NewFromMachineSet(ms *v1beta1.MachineSet) *MachineSetData {
+	data := &MachineSetData{
+		Name:      ms.Name,
+		Current:   ms.Status.Replicas,
+		Ready:     ms.Status.ReadyReplicas,
+		Available: ms.Status.AvailableReplicas,
+	}
+	// replicas defaults to one when unset
+	data.Desired = 1
+	if ms.Spec.Replicas != nil {
+		data.Desired = *ms.Spec.Replicas
+	}
+	if ms.Status.ErrorReason != nil {
+		data.ErrorReason = string(*ms.Status.ErrorReason)
+	}
+	if ms.Status.ErrorMessage != nil {
+		data.ErrorMessage = *ms.Status.ErrorMessage
+	}
+	return data
+}
+
+// ScaledDown reports whether the MachineSet has been scaled to zero
+func (m *MachineSetData) ScaledDown() bool {
+	return m.Desired == 0
+}
+
+// Behind reports whether the MachineSet has fewer ready replicas than it
+// wants, for example because machines are stuck provisioning
+func (m *MachineSetData) Behind() bool {
+	return m.Ready < m.Desired
+}
+
+// AddMachineSets records the cluster's MachineSets, sorted by name
+func (c *ClusterData) AddMachineSets(machineSets []*MachineSetData) {
+	sort.Slice(machineSets, func(i, j int) bool {
+		return machineSets[i].Name < machineSets[j].Name
+	})
+	c.MachineSets = machineSets
+}
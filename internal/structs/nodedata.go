@@ -6,44 +6,130 @@ import (
 
 	"github.com/openshift/api/machine/v1beta1"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/duration"
+	"nodepp/internal/config"
 	"nodepp/internal/consts"
 )
 
 type NodeData struct {
-	NodeName       string
-	MachineName    string
-	MachinePhase   string
-	InternalIP     string
-	Age            string
-	Roles          []string
-	Updating       bool
-	Missing        bool
-	Cordoned       bool
-	Ready          bool
-	MemoryPressure bool
-	DiskPressure   bool
-	Cpu            *ResourceMetric
-	Memory         *ResourceMetric
+	NodeName       string            `json:"nodeName"`
+	MachineName    string            `json:"machineName"`
+	MachinePhase   string            `json:"machinePhase"`
+	MachineError   *MachineError     `json:"machineError,omitempty"`
+	InstanceState  string            `json:"instanceState,omitempty"`
+	ProviderID     string            `json:"providerID,omitempty"`
+	InternalIP     string            `json:"internalIP"`
+	ExternalIP     string            `json:"externalIP,omitempty"`
+	Age            string            `json:"-"`
+	Created        metav1.Time       `json:"created"`
+	MachineSet     string            `json:"machineSet,omitempty"`
+	Zone           string            `json:"zone,omitempty"`
+	Region         string            `json:"region,omitempty"`
+	InstanceType   string            `json:"instanceType,omitempty"`
+	Roles          []string          `json:"roles"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	KubeletVersion string            `json:"kubeletVersion,omitempty"`
+	OSImage        string            `json:"osImage,omitempty"`
+	KernelVersion  string            `json:"kernelVersion,omitempty"`
+	RuntimeVersion string            `json:"runtimeVersion,omitempty"`
+	Pool           string            `json:"pool,omitempty"`
+	MCDState       string            `json:"mcdState,omitempty"`
+	MCDReason      string            `json:"mcdReason,omitempty"`
+	Updating       bool              `json:"updating"`
+	Missing        bool              `json:"missing"`
+	Cordoned       bool              `json:"cordoned"`
+	Ready          bool              `json:"ready"`
+	MemoryPressure bool              `json:"memoryPressure"`
+	DiskPressure   bool              `json:"diskPressure"`
+	Cpu            *ResourceMetric   `json:"cpu,omitempty"`
+	Memory         *ResourceMetric   `json:"memory,omitempty"`
+	Pods           *ResourceMetric   `json:"pods,omitempty"`
+
+	// MachineConditions are the conditions reported on the machine's status
+	MachineConditions []MachineCondition `json:"machineConditions,omitempty"`
+
+	// Node and Machine are the objects the row was built from, if any, for
+	// custom columns to read. They are shared with the informer caches and
+	// must not be modified.
+	Node    *v1.Node         `json:"-"`
+	Machine *v1beta1.Machine `json:"-"`
+}
+
+// MachineError is the terminal problem reported by a machine's provider
+type MachineError struct {
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// MachineCondition is a condition reported on a machine's status
+type MachineCondition struct {
+	Type     string `json:"type"`
+	Status   string `json:"status"`
+	Severity string `json:"severity,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	Message  string `json:"message,omitempty"`
 }
 
+// MachineProvisionTimeout is how long a machine may take to get a node
+// before it is considered stuck
+const MachineProvisionTimeout = 30 * time.Minute
+
 func (n *NodeData) NumRows() int {
 	// we don't have a need to extend a single node to multiple rows yet
 	maxRows := 1
 	return maxRows
 }
 
+// DeepCopy returns a copy of the node data that shares no mutable state,
+// other than the read-only source objects
+func (n *NodeData) DeepCopy() *NodeData {
+	c := *n
+	c.Roles = append([]string(nil), n.Roles...)
+	if n.Labels != nil {
+		c.Labels = make(map[string]string, len(n.Labels))
+		for k, v := range n.Labels {
+			c.Labels[k] = v
+		}
+	}
+	if n.MachineError != nil {
+		machineError := *n.MachineError
+		c.MachineError = &machineError
+	}
+	c.MachineConditions = append([]MachineCondition(nil), n.MachineConditions...)
+	if n.Cpu != nil {
+		c.Cpu = n.Cpu.DeepCopy()
+	}
+	if n.Memory != nil {
+		c.Memory = n.Memory.DeepCopy()
+	}
+	if n.Pods != nil {
+		c.Pods = n.Pods.DeepCopy()
+	}
+	return &c
+}
+
 func NewFromNode(node *v1.Node) (*NodeData, error) {
 	nodeData := new(NodeData)
 	nodeData.NodeName = node.Name
+	nodeData.Node = node
 
 	for _, addr := range node.Status.Addresses {
-		if addr.Type == v1.NodeInternalIP {
+		switch {
+		case addr.Type == v1.NodeInternalIP && nodeData.InternalIP == "":
 			nodeData.InternalIP = addr.Address
-			break
+		case addr.Type == v1.NodeExternalIP && nodeData.ExternalIP == "":
+			nodeData.ExternalIP = addr.Address
 		}
 	}
 
+	nodeInfo := node.Status.NodeInfo
+	nodeData.KubeletVersion = nodeInfo.KubeletVersion
+	nodeData.OSImage = nodeInfo.OSImage
+	nodeData.KernelVersion = nodeInfo.KernelVersion
+	nodeData.RuntimeVersion = nodeInfo.ContainerRuntimeVersion
+
+	nodeData.Created = node.CreationTimestamp
 	if node.CreationTimestamp.IsZero() {
 		nodeData.Age = "?"
 	} else {
@@ -64,8 +150,14 @@ func NewFromNode(node *v1.Node) (*NodeData, error) {
 			}
 		}
 	}
+	nodeData.MCDState = annotations[consts.Annotation_MachineConfigState]
+	nodeData.MCDReason = annotations[consts.Annotation_MachineConfigReason]
 	nodeData.Roles = make([]string, 0)
 	labels := node.GetLabels()
+	nodeData.Labels = labels
+	nodeData.Zone = labels[consts.Label_Zone]
+	nodeData.Region = labels[consts.Label_Region]
+	nodeData.InstanceType = labels[consts.Label_InstanceType]
 	for _, l := range []string{consts.Label_MasterNodeRole, consts.Label_InfraNodeRole, consts.Label_WorkerNodeRole} {
 		if _, ok := labels[l]; ok {
 			nodeData.Roles = append(nodeData.Roles, strings.SplitAfter(l, "/")[1])
@@ -88,6 +180,9 @@ func NewFromNode(node *v1.Node) (*NodeData, error) {
 	nodeData.Memory = &ResourceMetric{
 		Allocatable: node.Status.Allocatable.Memory().DeepCopy(),
 	}
+	nodeData.Pods = &ResourceMetric{
+		Allocatable: node.Status.Allocatable.Pods().DeepCopy(),
+	}
 
 	return nodeData, nil
 }
@@ -97,17 +192,97 @@ func NewFromMachine(machine *v1beta1.Machine) (*NodeData, error) {
 
 	// set the machine name
 	nodeData.MachineName = machine.Name
+	nodeData.Machine = machine
 
 	// get the node name
 	if machine.Status.NodeRef != nil && machine.Status.NodeRef.Kind == "Node" {
 		nodeData.NodeName = machine.Status.NodeRef.Name
 	}
+	nodeData.Missing = nodeData.NodeName == ""
 
 	// set the machine phase
 	if machine.Status.Phase != nil {
 		nodeData.MachinePhase = *machine.Status.Phase
 	}
 
+	// record why the machine failed, and what its provider reports
+	if machine.Status.ErrorReason != nil || machine.Status.ErrorMessage != nil {
+		nodeData.MachineError = new(MachineError)
+		if machine.Status.ErrorReason != nil {
+			nodeData.MachineError.Reason = string(*machine.Status.ErrorReason)
+		}
+		if machine.Status.ErrorMessage != nil {
+			nodeData.MachineError.Message = *machine.Status.ErrorMessage
+		}
+	}
+	nodeData.InstanceState = machine.Annotations[consts.Annotation_InstanceState]
+	if machine.Spec.ProviderID != nil {
+		nodeData.ProviderID = *machine.Spec.ProviderID
+	}
+	for _, c := range machine.Status.Conditions {
+		nodeData.MachineConditions = append(nodeData.MachineConditions, MachineCondition{
+			Type:     string(c.Type),
+			Status:   string(c.Status),
+			Severity: string(c.Severity),
+			Reason:   c.Reason,
+			Message:  c.Message,
+		})
+	}
+
+	nodeData.Created = machine.CreationTimestamp
+	nodeData.MachineSet = machine.Labels[consts.Label_MachineSet]
+	for _, owner := range machine.OwnerReferences {
+		if owner.Kind == "MachineSet" {
+			nodeData.MachineSet = owner.Name
+		}
+	}
+	nodeData.Zone = machine.Labels[consts.Label_MachineZone]
+	nodeData.Region = machine.Labels[consts.Label_MachineRegion]
+	nodeData.InstanceType = machine.Labels[consts.Label_MachineType]
+
 	// that's all we care about for now
 	return nodeData, nil
 }
+
+// Hot reports whether the node's usage, requests or pod count is above the
+// given thresholds
+func (n *NodeData) Hot(t config.Thresholds) bool {
+	for _, r := range []struct {
+		metric    *ResourceMetric
+		threshold float64
+	}{{n.Cpu, t.CPU}, {n.Memory, t.Memory}} {
+		if r.metric != nil && (r.metric.UtilizationPercent() > r.threshold || r.metric.RequestsPercent() > r.threshold) {
+			return true
+		}
+	}
+	return n.Pods != nil && n.Pods.UtilizationPercent() > t.Pods
+}
+
+// ControlPlane reports whether the row is a control-plane node or machine.
+// Control-plane machines are not owned by a MachineSet.
+func (n *NodeData) ControlPlane() bool {
+	for _, role := range n.Roles {
+		if role == "master" {
+			return true
+		}
+	}
+	if _, ok := n.Labels[consts.Label_ControlPlane]; ok {
+		return true
+	}
+	return n.Machine != nil && n.Machine.Labels[consts.Label_MachineRole] == "master"
+}
+
+// MachineStuck reports whether a machine has been provisioning for longer
+// than MachineProvisionTimeout without getting a node
+func (n *NodeData) MachineStuck(now time.Time) bool {
+	if n.MachinePhase != "Provisioning" && n.MachinePhase != "Provisioned" {
+		return false
+	}
+	return n.NodeName == "" && !n.Created.IsZero() && now.Sub(n.Created.Time) > MachineProvisionTimeout
+}
+
+// MachineProblem reports whether a machine has failed, reported an error or
+// is stuck provisioning
+func (n *NodeData) MachineProblem(now time.Time) bool {
+	return n.MachinePhase == "Failed" || n.MachineError != nil || n.MachineStuck(now)
+}
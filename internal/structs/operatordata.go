@@ -0,0 +1,74 @@
+package structs
+
+import (
+	v1 "github.com/openshift/api/config/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// OperatorStatus summarises the health of a single cluster operator
+type OperatorStatus struct {
+	Name        string      `json:"name"`
+	Version     string      `json:"version,omitempty"`
+	Down        bool        `json:"down"`
+	Progressing bool        `json:"progressing"`
+	Degraded    bool        `json:"degraded"`
+	Upgradeable bool        `json:"upgradeable"`
+	Since       metav1.Time `json:"since,omitempty"`
+	Message     string      `json:"message,omitempty"`
+
+	// ProgressingSince is when the operator started progressing, if it is
+	ProgressingSince *metav1.Time `json:"progressingSince,omitempty"`
+}
+
+// NewFromClusterOperator summarises the conditions reported by a cluster operator
+func NewFromClusterOperator(co *v1.ClusterOperator) *OperatorStatus {
+	// operators are upgradeable unless they say otherwise
+	status := &OperatorStatus{Name: co.Name, Upgradeable: true}
+
+	for _, version := range co.Status.Versions {
+		if version.Name == "operator" {
+			status.Version = version.Version
+		}
+	}
+
+	var availableMessage, progressingMessage, degradedMessage, upgradeableMessage string
+	for _, cnd := range co.Status.Conditions {
+		switch cnd.Type {
+		case v1.OperatorAvailable:
+			status.Down = cnd.Status == v1.ConditionFalse
+			availableMessage = cnd.Message
+		case v1.OperatorProgressing:
+			status.Progressing = cnd.Status == v1.ConditionTrue
+			if status.Progressing && !cnd.LastTransitionTime.IsZero() {
+				status.ProgressingSince = cnd.LastTransitionTime.DeepCopy()
+			}
+			progressingMessage = cnd.Message
+		case v1.OperatorDegraded:
+			status.Degraded = cnd.Status == v1.ConditionTrue
+			degradedMessage = cnd.Message
+		case v1.OperatorUpgradeable:
+			status.Upgradeable = cnd.Status != v1.ConditionFalse
+			upgradeableMessage = cnd.Message
+		}
+		// track the most recent change to any condition
+		if cnd.LastTransitionTime.After(status.Since.Time) {
+			status.Since = cnd.LastTransitionTime
+		}
+	}
+
+	// report the message for the most severe condition
+	switch {
+	case status.Down:
+		status.Message = availableMessage
+	case status.Degraded:
+		status.Message = degradedMessage
+	case status.Progressing:
+		status.Message = progressingMessage
+	case !status.Upgradeable:
+		status.Message = upgradeableMessage
+	default:
+		status.Message = availableMessage
+	}
+
+	return status
+}
@@ -0,0 +1,39 @@
+package structs
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/openshift/api/config/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewFromClusterOperator(t *testing.T) {
+	older := metav1.NewTime(time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC))
+	newer := metav1.NewTime(time.Date(2023, 6, 2, 0, 0, 0, 0, time.UTC))
+	co := &v1.ClusterOperator{
+		ObjectMeta: metav1.ObjectMeta{Name: "network"},
+		Status: v1.ClusterOperatorStatus{
+			Versions: []v1.OperandVersion{{Name: "ovnkube", Version: "4.13.0"}, {Name: "operator", Version: "4.13.1"}},
+			Conditions: []v1.ClusterOperatorStatusCondition{
+				{Type: v1.OperatorAvailable, Status: v1.ConditionTrue, LastTransitionTime: older, Message: "available"},
+				{Type: v1.OperatorProgressing, Status: v1.ConditionTrue, LastTransitionTime: newer, Message: "rolling out"},
+				{Type: v1.OperatorDegraded, Status: v1.ConditionFalse, LastTransitionTime: older},
+			},
+		},
+	}
+
+	status := NewFromClusterOperator(co)
+	if status.Version != "4.13.1" {
+		t.Errorf("expected the operator version, got %q", status.Version)
+	}
+	if status.Down || status.Degraded || !status.Progressing || !status.Upgradeable {
+		t.Errorf("unexpected conditions: %+v", status)
+	}
+	if !status.Since.Equal(&newer) || status.ProgressingSince == nil || !status.ProgressingSince.Equal(&newer) {
+		t.Errorf("expected the latest transition time, got %v", status.Since)
+	}
+	if status.Message != "rolling out" {
+		t.Errorf("expected the progressing message, got %q", status.Message)
+	}
+}
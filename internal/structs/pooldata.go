@@ -0,0 +1,101 @@
+package structs
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MachineConfigPoolResource identifies MachineConfigPools, which are read
+// through the dynamic client as their types are not part of openshift/api
+var MachineConfigPoolResource = schema.GroupVersionResource{
+	Group:    "machineconfiguration.openshift.io",
+	Version:  "v1",
+	Resource: "machineconfigpools",
+}
+
+// machineConfigPool mirrors the MachineConfigPool fields that nodepp reads
+type machineConfigPool struct {
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              struct {
+		NodeSelector *metav1.LabelSelector `json:"nodeSelector"`
+		Paused       bool                  `json:"paused"`
+	} `json:"spec"`
+	Status struct {
+		MachineCount            int32 `json:"machineCount"`
+		UpdatedMachineCount     int32 `json:"updatedMachineCount"`
+		ReadyMachineCount       int32 `json:"readyMachineCount"`
+		UnavailableMachineCount int32 `json:"unavailableMachineCount"`
+		DegradedMachineCount    int32 `json:"degradedMachineCount"`
+		Conditions              []struct {
+			Type    string `json:"type"`
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		} `json:"conditions"`
+	} `json:"status"`
+}
+
+// PoolData summarises the rollout progress of a MachineConfigPool
+type PoolData struct {
+	Name                    string `json:"name"`
+	MachineCount            int32  `json:"machineCount"`
+	UpdatedMachineCount     int32  `json:"updatedMachineCount"`
+	ReadyMachineCount       int32  `json:"readyMachineCount"`
+	UnavailableMachineCount int32  `json:"unavailableMachineCount"`
+	DegradedMachineCount    int32  `json:"degradedMachineCount"`
+	Paused                  bool   `json:"paused"`
+	Updating                bool   `json:"updating"`
+	Degraded                bool   `json:"degraded"`
+	DegradedMessage         string `json:"degradedMessage,omitempty"`
+
+	selector labels.Selector
+}
+
+func NewFromMachineConfigPool(obj *unstructured.Unstructured) (*PoolData, error) {
+	mcp := new(machineConfigPool)
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, mcp); err != nil {
+		return nil, err
+	}
+
+	poolData := &PoolData{
+		Name:                    mcp.Name,
+		MachineCount:            mcp.Status.MachineCount,
+		UpdatedMachineCount:     mcp.Status.UpdatedMachineCount,
+		ReadyMachineCount:       mcp.Status.ReadyMachineCount,
+		UnavailableMachineCount: mcp.Status.UnavailableMachineCount,
+		DegradedMachineCount:    mcp.Status.DegradedMachineCount,
+		Paused:                  mcp.Spec.Paused,
+	}
+	for _, c := range mcp.Status.Conditions {
+		if c.Type == "Updating" && c.Status == "True" {
+			poolData.Updating = true
+		}
+		if c.Type == "Degraded" && c.Status == "True" {
+			poolData.Degraded = true
+			poolData.DegradedMessage = c.Message
+		}
+	}
+
+	// a pool without a selector matches no nodes
+	poolData.selector = labels.Nothing()
+	if mcp.Spec.NodeSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(mcp.Spec.NodeSelector)
+		if err != nil {
+			return nil, err
+		}
+		poolData.selector = selector
+	}
+
+	return poolData, nil
+}
+
+// Matches reports whether a node with the given labels belongs to the pool
+func (p *PoolData) Matches(nodeLabels map[string]string) bool {
+	if p.selector == nil {
+		return false
+	}
+	return p.selector.Matches(labels.Set(nodeLabels))
+}
@@ -3,6 +3,42 @@ package structs
 import "k8s.io/apimachinery/pkg/api/resource"
 
 type ResourceMetric struct {
-	Allocatable resource.Quantity
-	Utilization resource.Quantity
+	Allocatable resource.Quantity `json:"allocatable"`
+	Utilization resource.Quantity `json:"utilization"`
+	Requests    resource.Quantity `json:"requests"`
+	Limits      resource.Quantity `json:"limits"`
+}
+
+// DeepCopy returns a copy of the resource metric
+func (r *ResourceMetric) DeepCopy() *ResourceMetric {
+	return &ResourceMetric{
+		Allocatable: r.Allocatable.DeepCopy(),
+		Utilization: r.Utilization.DeepCopy(),
+		Requests:    r.Requests.DeepCopy(),
+		Limits:      r.Limits.DeepCopy(),
+	}
+}
+
+// UtilizationPercent returns utilization as a percentage of allocatable
+func (r *ResourceMetric) UtilizationPercent() float64 {
+	if r.Allocatable.IsZero() {
+		return 0
+	}
+	return float64(r.Utilization.MilliValue()) / float64(r.Allocatable.MilliValue()) * 100
+}
+
+// RequestsPercent returns the sum of pod requests as a percentage of allocatable
+func (r *ResourceMetric) RequestsPercent() float64 {
+	if r.Allocatable.IsZero() {
+		return 0
+	}
+	return float64(r.Requests.MilliValue()) / float64(r.Allocatable.MilliValue()) * 100
+}
+
+// LimitsPercent returns the sum of pod limits as a percentage of allocatable
+func (r *ResourceMetric) LimitsPercent() float64 {
+	if r.Allocatable.IsZero() {
+		return 0
+	}
+	return float64(r.Limits.MilliValue()) / float64(r.Allocatable.MilliValue()) * 100
 }
@@ -0,0 +1,166 @@
+package structs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"nodepp/internal/consts"
+)
+
+// Fields that rows can be sorted by
+const (
+	SortRole       = "role"
+	SortName       = "name"
+	SortAge        = "age"
+	SortCPU        = "cpu"
+	SortMemory     = "memory"
+	SortPods       = "pods"
+	SortZone       = "zone"
+	SortMachineSet = "machineset"
+	SortStatus     = "status"
+)
+
+// SortKey is a field to sort rows by, and its direction
+type SortKey struct {
+	Field      string
+	Descending bool
+}
+
+// DefaultSort orders rows by role, as nodepp always has
+var DefaultSort = []SortKey{{Field: SortRole}}
+
+// rowComparators compare two rows by a field, returning a negative number
+// when a sorts before b in ascending order
+var rowComparators = map[string]func(a, b *NodeData) int{
+	SortRole: func(a, b *NodeData) int {
+		return leadingRoleOrder(a) - leadingRoleOrder(b)
+	},
+	SortName: func(a, b *NodeData) int {
+		return strings.Compare(a.rowName(), b.rowName())
+	},
+	// youngest first, with rows of unknown age last
+	SortAge: func(a, b *NodeData) int {
+		switch {
+		case a.Created.Equal(&b.Created):
+			return 0
+		case a.Created.IsZero():
+			return 1
+		case b.Created.IsZero():
+			return -1
+		case b.Created.Before(&a.Created):
+			return -1
+		}
+		return 1
+	},
+	SortCPU: func(a, b *NodeData) int {
+		return comparePercent(a.Cpu, b.Cpu)
+	},
+	SortMemory: func(a, b *NodeData) int {
+		return comparePercent(a.Memory, b.Memory)
+	},
+	SortPods: func(a, b *NodeData) int {
+		return comparePercent(a.Pods, b.Pods)
+	},
+	SortZone: func(a, b *NodeData) int {
+		return strings.Compare(a.Zone, b.Zone)
+	},
+	SortMachineSet: func(a, b *NodeData) int {
+		return strings.Compare(a.MachineSet, b.MachineSet)
+	},
+	// healthy rows first, so sort descending to put problems at the top
+	SortStatus: func(a, b *NodeData) int {
+		return a.severity() - b.severity()
+	},
+}
+
+// SortFields lists every field that rows can be sorted by
+var SortFields = []string{SortRole, SortName, SortAge, SortCPU, SortMemory, SortPods, SortZone, SortMachineSet, SortStatus}
+
+// ParseSortKeys parses sort keys such as "role" or "cpu:desc". Later keys
+// break ties between rows that are equal on earlier ones.
+func ParseSortKeys(specs []string) ([]SortKey, error) {
+	keys := make([]SortKey, 0, len(specs))
+	for _, spec := range specs {
+		field, direction, _ := strings.Cut(spec, ":")
+		if _, ok := rowComparators[field]; !ok {
+			return nil, fmt.Errorf("unknown sort field %q, expected one of %s", field, strings.Join(SortFields, "|"))
+		}
+		key := SortKey{Field: field}
+		switch direction {
+		case "", "asc":
+		case "desc":
+			key.Descending = true
+		default:
+			return nil, fmt.Errorf("unknown sort direction %q for %s, expected asc or desc", direction, field)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Sort orders the cluster's rows by the given keys, keeping the existing
+// order of rows that are equal on every key
+func (c *ClusterData) Sort(keys []SortKey) {
+	sort.SliceStable(c.Nodes, func(i, j int) bool {
+		for _, key := range keys {
+			cmp := rowComparators[key.Field](c.Nodes[i], c.Nodes[j])
+			if key.Descending {
+				cmp = -cmp
+			}
+			if cmp != 0 {
+				return cmp < 0
+			}
+		}
+		return false
+	})
+}
+
+// leadingRoleOrder ranks a row by its leading role, with rows that have no
+// role last
+func leadingRoleOrder(n *NodeData) int {
+	if len(n.Roles) == 0 {
+		return roleSortOrder("") + 1
+	}
+	return roleSortOrder(n.Roles[0])
+}
+
+// comparePercent compares utilization as a share of allocatable, with rows
+// that have no data first
+func comparePercent(a, b *ResourceMetric) int {
+	var pa, pb float64 = -1, -1
+	if a != nil {
+		pa = a.UtilizationPercent()
+	}
+	if b != nil {
+		pb = b.UtilizationPercent()
+	}
+	switch {
+	case pa < pb:
+		return -1
+	case pa > pb:
+		return 1
+	}
+	return 0
+}
+
+// rowName is the node name, or the machine name for machines without a node
+func (n *NodeData) rowName() string {
+	if n.NodeName != "" {
+		return n.NodeName
+	}
+	return n.MachineName
+}
+
+// severity ranks how much attention a row needs, from 0 for healthy rows
+func (n *NodeData) severity() int {
+	switch {
+	case n.MachinePhase == "Failed" || (n.NodeName != "" && !n.Ready):
+		return 3
+	case n.Cordoned || n.MemoryPressure || n.DiskPressure || n.MCDState == consts.MachineConfigStateDegraded:
+		return 2
+	case n.Updating || n.NodeName == "":
+		return 1
+	}
+	return 0
+}
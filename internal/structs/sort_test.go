@@ -0,0 +1,59 @@
+package structs
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func cpu(utilization string) *ResourceMetric {
+	return &ResourceMetric{Allocatable: resource.MustParse("4"), Utilization: resource.MustParse(utilization)}
+}
+
+func TestSort(t *testing.T) {
+	created := func(days int) metav1.Time {
+		return metav1.NewTime(time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -days))
+	}
+	nodes := func() []*NodeData {
+		return []*NodeData{
+			{NodeName: "worker-b", Roles: []string{"worker"}, Ready: true, Cpu: cpu("1"), Created: created(10)},
+			{NodeName: "master-a", Roles: []string{"master"}, Ready: true, Cpu: cpu("3"), Created: created(30)},
+			{NodeName: "worker-a", Roles: []string{"worker"}, Ready: true, Cpu: cpu("3"), Created: created(1)},
+			{NodeName: "worker-c", Roles: []string{"worker"}, Cpu: cpu("2"), Created: created(20)},
+		}
+	}
+
+	tests := []struct {
+		specs    []string
+		expected []string
+	}{
+		{[]string{"role", "name"}, []string{"master-a", "worker-a", "worker-b", "worker-c"}},
+		{[]string{"cpu:desc", "name:desc"}, []string{"worker-a", "master-a", "worker-c", "worker-b"}},
+		{[]string{"age"}, []string{"worker-a", "worker-b", "worker-c", "master-a"}},
+		{[]string{"status:desc", "role"}, []string{"worker-c", "master-a", "worker-b", "worker-a"}},
+	}
+	for _, test := range tests {
+		keys, err := ParseSortKeys(test.specs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cd := &ClusterData{Nodes: nodes()}
+		cd.Sort(keys)
+		for i, n := range cd.Nodes {
+			if n.NodeName != test.expected[i] {
+				t.Errorf("%v: expected %v, got %s at %d", test.specs, test.expected, n.NodeName, i)
+				break
+			}
+		}
+	}
+}
+
+func TestParseSortKeysRejectsUnknownKeys(t *testing.T) {
+	for _, spec := range []string{"colour", "cpu:up"} {
+		if _, err := ParseSortKeys([]string{spec}); err == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+}
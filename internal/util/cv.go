@@ -2,6 +2,7 @@ package util
 
 import (
 	"fmt"
+	"time"
 
 	v1 "github.com/openshift/api/config/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -26,3 +27,55 @@ func GetCurrentVersion(clusterVersion *v1.ClusterVersion) (string, error) {
 
 	return gotVersion, nil
 }
+
+// ClusterVersionFailing is the condition the CVO reports when an update cannot be applied
+const ClusterVersionFailing v1.ClusterStatusConditionType = "Failing"
+
+// GetCondition returns the cluster version condition of the given type, or nil
+func GetCondition(clusterVersion *v1.ClusterVersion, conditionType v1.ClusterStatusConditionType) *v1.ClusterOperatorStatusCondition {
+	for i := range clusterVersion.Status.Conditions {
+		if clusterVersion.Status.Conditions[i].Type == conditionType {
+			return &clusterVersion.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// GetUpdateInProgress returns the history entry for an update that has not yet
+// completed, or nil if the cluster is not updating
+func GetUpdateInProgress(clusterVersion *v1.ClusterVersion) *v1.UpdateHistory {
+	// history is ordered with the most recent update first
+	if len(clusterVersion.Status.History) == 0 {
+		return nil
+	}
+	latest := &clusterVersion.Status.History[0]
+	if latest.State == v1.PartialUpdate && latest.CompletionTime == nil {
+		return latest
+	}
+	return nil
+}
+
+// GetPartialUpdates returns the updates that were never completely applied,
+// excluding any update still in progress
+func GetPartialUpdates(clusterVersion *v1.ClusterVersion) []v1.UpdateHistory {
+	inProgress := GetUpdateInProgress(clusterVersion)
+	partial := make([]v1.UpdateHistory, 0)
+	for i, history := range clusterVersion.Status.History {
+		if i == 0 && inProgress != nil {
+			continue
+		}
+		if history.State == v1.PartialUpdate {
+			partial = append(partial, history)
+		}
+	}
+	return partial
+}
+
+// IsUpdateStuck reports whether an update has been in progress for longer than threshold
+func IsUpdateStuck(clusterVersion *v1.ClusterVersion, now time.Time, threshold time.Duration) bool {
+	inProgress := GetUpdateInProgress(clusterVersion)
+	if inProgress == nil || inProgress.StartedTime.IsZero() {
+		return false
+	}
+	return now.Sub(inProgress.StartedTime.Time) > threshold
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/klog/v2"
 	"nodepp/cmd"
@@ -11,6 +12,10 @@ func main() {
 
 	podInspectCmd := cmd.NewNodePPCommand(genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr})
 	if err := podInspectCmd.Execute(); err != nil {
+		var exitErr *cmd.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		klog.Error(err)
 		os.Exit(1)
 	}